@@ -0,0 +1,199 @@
+// Command migrate applies the migrations of db/<name>/migrations embedded in the binary.
+//
+//	migrate -database-url <url> [-db sample_db] up [N]      apply all or the next N migrations
+//	migrate -database-url <url> [-db sample_db] down [N]    revert the last or the last N migrations
+//	migrate -database-url <url> [-db sample_db] version     print the current version
+//	migrate -database-url <url> [-db sample_db] force V     set the version and clear the dirty flag
+//	migrate [-db sample_db] create TITLE                    create the next up and down migration files under ./db
+//
+// database url defaults to DATABASE_URL env variable. Alternatively, -config <name> [-config-dir config] connects
+// with the Storage section of the config of the ENVIRONMENT as the services do, resolving the credentials
+// from UsernameSource and PasswordSource.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"syscall"
+
+	"github.com/nitesh237/go-server-template/db"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"github.com/nitesh237/go-server-template/pkg/storage/migrate"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+func main() {
+	databaseURL := flag.String("database-url", os.Getenv("DATABASE_URL"), "postgres connection url")
+	configDir := flag.String("config-dir", "config", "dir of the config files")
+	configName := flag.String("config", "", "name of the config having the Storage section, takes precedence over the database url")
+	dbName := flag.String("db", "sample_db", "name of the database dir under db/")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	connect := func() (*gorm.DB, error) {
+		if *configName != "" {
+			return connectWithConfig(*configDir, *configName)
+		}
+		return connectWithURL(*databaseURL)
+	}
+
+	if err := run(ctx, connect, *dbName, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, connect func() (*gorm.DB, error), dbName string, args []string) error {
+	if len(args) == 0 {
+		return errors.Wrap(errors.ErrInvalidArgument, "command is required: up, down, version, force or create")
+	}
+
+	dir := path.Join(dbName, "migrations")
+	if args[0] == "create" {
+		if len(args) < 2 {
+			return errors.Wrap(errors.ErrInvalidArgument, "title of the migration is required")
+		}
+		return create(path.Join("db", dir), args[1])
+	}
+
+	conn, err := connect()
+	if err != nil {
+		return errors.Wrap(err, "failed to connect to the database")
+	}
+	defer func() { _ = storage.CloseDB(conn) }()
+
+	migrator, err := migrate.NewMigrator(conn, db.Migrations, dir)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		n, err := getIntArg(args, 0)
+		if err != nil {
+			return err
+		}
+		if err = migrator.Up(ctx, n); err != nil {
+			return err
+		}
+	case "down":
+		n, err := getIntArg(args, 1)
+		if err != nil {
+			return err
+		}
+		if err = migrator.Down(ctx, n); err != nil {
+			return err
+		}
+	case "force":
+		v, err := getIntArg(args, -1)
+		if err != nil || v < 0 {
+			return errors.Wrap(errors.ErrInvalidArgument, "version to force is required")
+		}
+		if err = migrator.Force(ctx, uint64(v)); err != nil {
+			return err
+		}
+	case "version":
+	default:
+		return errors.Wrap(errors.ErrInvalidArgument, "unknown command %s", args[0])
+	}
+
+	version, dirty, err := migrator.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Printf("%d (dirty)\n", version)
+		return nil
+	}
+	fmt.Println(version)
+	return nil
+}
+
+// connectWithURL connects to the database url with the credentials in it
+func connectWithURL(databaseURL string) (*gorm.DB, error) {
+	if databaseURL == "" {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "database url or config is required")
+	}
+
+	return gorm.Open(postgres.Open(databaseURL), &gorm.Config{
+		Logger: gormlogger.Default.LogMode(gormlogger.Warn),
+	})
+}
+
+// connectWithConfig connects to the primary of the Storage section of the config, see cfg.Load
+func connectWithConfig(configDir, configName string) (*gorm.DB, error) {
+	conf, err := cfg.Load[struct{ Storage *cfg.Storage }](configDir, configName, cfg.ConfigTypeYaml)
+	if err != nil {
+		return nil, err
+	}
+	if conf.Storage == nil || conf.Storage.PgDsn == nil {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "Storage.PgDsn is missing in the config %s", configName)
+	}
+
+	// migrations are applied on the primary only
+	storageConf := *conf.Storage
+	storageConf.Replicas = nil
+	if storageConf.GormV2Conf == nil {
+		storageConf.GormV2Conf = &cfg.GormV2Conf{}
+	}
+
+	env, err := cfg.GetEnvironment()
+	if err != nil {
+		return nil, err
+	}
+	logger, err := log.NewZapLogger(env, &cfg.Logging{})
+	if err != nil {
+		return nil, err
+	}
+
+	return storage.NewPostgresDB(&storageConf, logger)
+}
+
+// create writes the next version of up and down migration files in dir
+func create(dir, title string) error {
+	migrations, err := migrate.LoadMigrations(os.DirFS("."), dir)
+	if err != nil {
+		return err
+	}
+
+	var next uint64 = 1
+	if len(migrations) > 0 {
+		next = migrations[len(migrations)-1].Version + 1
+	}
+
+	for _, direction := range []string{"up", "down"} {
+		name := path.Join(dir, fmt.Sprintf("%06d_%s.%s.sql", next, title, direction))
+		if err = os.WriteFile(name, nil, 0o644); err != nil {
+			return errors.Wrap(err, "failed to create %s", name)
+		}
+		fmt.Println(name)
+	}
+
+	return nil
+}
+
+func getIntArg(args []string, defaultVal int) (int, error) {
+	if len(args) < 2 || args[1] == "" {
+		return defaultVal, nil
+	}
+
+	n, err := strconv.Atoi(args[1])
+	if err != nil {
+		return 0, errors.Wrap(errors.ErrInvalidArgument, "invalid number %s", args[1])
+	}
+
+	return n, nil
+}
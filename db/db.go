@@ -0,0 +1,9 @@
+// Package db embeds the migrations of all the databases, refer db/<name>/migrations
+package db
+
+import "embed"
+
+// Migrations holds db/<name>/migrations/*.sql, use <name>/migrations as the dir of the migrations of a database
+//
+//go:embed */migrations/*.sql
+var Migrations embed.FS
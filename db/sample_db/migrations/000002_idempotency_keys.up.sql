@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    idempotency_key       VARCHAR(255) PRIMARY KEY,
+    request_fingerprint   VARCHAR(64)  NOT NULL,
+    owner_token           VARCHAR(64)  NOT NULL DEFAULT '',
+    status                VARCHAR(32)  NOT NULL,
+    response_status_code  INT,
+    response_content_type VARCHAR(255),
+    response_headers      JSONB,
+    response_body         BYTEA,
+    locked_until          TIMESTAMPTZ  NOT NULL,
+    expires_at            TIMESTAMPTZ  NOT NULL,
+    created_at            TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
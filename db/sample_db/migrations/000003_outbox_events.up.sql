@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id              BIGSERIAL    PRIMARY KEY,
+    topic           VARCHAR(255) NOT NULL,
+    event_key       VARCHAR(255) NOT NULL DEFAULT '',
+    payload         JSONB        NOT NULL,
+    headers         JSONB,
+    status          VARCHAR(32)  NOT NULL,
+    attempts        INT          NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    last_error      TEXT         NOT NULL DEFAULT '',
+    published_at    TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events (next_attempt_at, id) WHERE status = 'PENDING';
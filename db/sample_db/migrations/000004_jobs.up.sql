@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS jobs (
+    id           BIGSERIAL    PRIMARY KEY,
+    job_type     VARCHAR(255) NOT NULL,
+    payload      JSONB        NOT NULL,
+    status       VARCHAR(32)  NOT NULL,
+    priority     INT          NOT NULL DEFAULT 0,
+    attempts     INT          NOT NULL DEFAULT 0,
+    run_at       TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    locked_until TIMESTAMPTZ,
+    last_error   TEXT         NOT NULL DEFAULT '',
+    completed_at TIMESTAMPTZ,
+    created_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_jobs_pending ON jobs (priority DESC, run_at, id) WHERE status = 'PENDING';
+CREATE INDEX IF NOT EXISTS idx_jobs_running ON jobs (locked_until) WHERE status = 'RUNNING';
@@ -38,14 +38,14 @@ func (a *staticBearerAuthenticator) GetHTTPMiddleware() func(next http.Handler)
 	return func(next http.Handler) http.HandlerFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			a.logger.Debug(r.Context(), "Executing Auth Middleware")
-			_, err := a.authStrategy.Authenticate(r.Context(), r)
+			info, err := a.authStrategy.Authenticate(r.Context(), r)
 			if err != nil {
 				a.logger.Error(r.Context(), "authentication failed", zap.Error(err))
 				code := http.StatusUnauthorized
 				http.Error(w, http.StatusText(code), code)
 				return
 			}
-			next.ServeHTTP(w, r)
+			next.ServeHTTP(w, auth.RequestWithUser(info, r))
 		})
 	}
 }
@@ -58,12 +58,19 @@ func (a *staticBearerAuthenticator) GetGinMiddleware() gin.HandlerFunc {
 			return
 		}
 
-		_, err := a.authStrategy.Authenticate(c.Request.Context(), c.Request)
+		info, err := a.authStrategy.Authenticate(c.Request.Context(), c.Request)
 		if err != nil {
 			code := http.StatusUnauthorized
 			c.AbortWithStatusJSON(http.StatusUnauthorized, errors.NewErrorResponseWithCode(http.StatusText(code), err.Error(), http.StatusUnauthorized))
 			return
 		}
+		c.Request = auth.RequestWithUser(info, c.Request)
 		c.Next()
 	}
 }
+
+// UserFromRequest returns the user info attached to the request by the authenticator middlewares.
+// Returns nil if the request was not authenticated.
+func UserFromRequest(r *http.Request) auth.Info {
+	return auth.User(r)
+}
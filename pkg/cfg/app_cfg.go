@@ -13,6 +13,8 @@ type Application struct {
 	ServerPorts *ServerPorts
 	Logging     *Logging
 	Auth        *Auth
+	RateLimit   *ServerRateLimit
+	Idempotency *Idempotency
 }
 
 // config struct for TemporalWorkerApplication
@@ -48,6 +50,21 @@ type Auth struct {
 	ConfigFilePath string
 }
 
+// Idempotency holds the parameters for handling the requests made with an Idempotency-Key header
+type Idempotency struct {
+	// Duration for which the response of a key is stored and replayed.
+	// If not set or set to 0, a default of 24 hours will be used.
+	KeyTTL time.Duration
+
+	// Duration after which an in progress key can be claimed by another request,
+	// e.g. when the process handling the original request has crashed.
+	// If not set or set to 0, a default of 1 minute will be used.
+	LockTimeout time.Duration
+
+	// RequireKey rejects the requests without an Idempotency-Key header
+	RequireKey bool
+}
+
 type HttpClient struct {
 
 	// Transport layer configurations
@@ -108,6 +125,15 @@ type HttpClient struct {
 	// RetryPolicy specifies the retry policy for the client.
 	// Optional: default is no retry and is only used when initializing http.NewRetryableHttpClient
 	RetryParams *RetryParams
+
+	// Limits specifies the rate limit and bulkhead applied across all the requests made by the client.
+	// Optional: default is no limit
+	Limits *Limits
+
+	// EndpointLimitsList specifies the limits applied to the requests made to a particular endpoint.
+	// These are applied in addition to Limits.
+	// Optional: default is no limit
+	EndpointLimitsList EndpointLimitsList
 }
 
 type RetryParams struct {
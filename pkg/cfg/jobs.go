@@ -0,0 +1,43 @@
+package cfg
+
+import "time"
+
+type Jobs struct {
+	// maximum number of jobs run concurrently by the worker pool, 10 if not set
+	Concurrency int
+
+	// interval between the polls of the worker pool when there are no due jobs, 1s if not set
+	PollInterval time.Duration
+
+	// RetryParams specify how the failed jobs are retried, the jobs are moved to the dead-letter state once the attempts
+	// are exhausted. Can be overridden per job type through JobParamsList.
+	// Optional: default is exponential backoff from 1s to 1h for 10 attempts
+	RetryParams *RetryParams
+
+	// Timeout of a single run of a job, can be overridden per job type through JobParamsList.
+	// Optional: default is 5m
+	Timeout time.Duration
+
+	JobParamsList JobParamsList
+}
+
+type JobParams struct {
+	// type of the job for which config is defined
+	JobType string
+
+	RetryParams *RetryParams
+
+	Timeout time.Duration
+}
+
+type JobParamsList []*JobParams
+
+func (l JobParamsList) GetJobParamsMap() map[string]*JobParams {
+	mp := map[string]*JobParams{}
+
+	for _, jp := range l {
+		mp[jp.JobType] = jp
+	}
+
+	return mp
+}
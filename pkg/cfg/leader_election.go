@@ -0,0 +1,14 @@
+package cfg
+
+import "time"
+
+type LeaderElection struct {
+	// name of the election, the instances with the same name elect a single leader among them
+	Name string
+
+	// interval between the attempts of a follower to become the leader, 5s if not set
+	RetryInterval time.Duration
+
+	// interval at which the leader checks that it still holds the leadership, 5s if not set
+	CheckInterval time.Duration
+}
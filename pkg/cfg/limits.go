@@ -0,0 +1,127 @@
+package cfg
+
+import (
+	"fmt"
+	"time"
+)
+
+// LimitMode defines the behaviour of a limiter when the limit is hit
+type LimitMode string
+
+const (
+	// LimitModeWait blocks the caller till the limit frees up or MaxWait/context deadline is reached
+	LimitModeWait LimitMode = "WAIT"
+	// LimitModeFailFast rejects the call immediately when the limit is hit
+	LimitModeFailFast LimitMode = "FAIL_FAST"
+)
+
+// RateLimit holds the parameters for a token bucket rate limiter
+type RateLimit struct {
+	// Number of tokens added to the bucket per second i.e. sustained requests per second.
+	// If not set or set to 0, rate is unlimited.
+	RequestsPerSecond float64
+	// Maximum number of tokens in the bucket i.e. maximum burst of requests.
+	// If not set or set to 0, a default burst of 1 will be used.
+	Burst int
+	// Mode defines the behaviour once the bucket is empty. Default is LimitModeWait.
+	Mode LimitMode
+	// Maximum time to wait for a token in LimitModeWait.
+	// If not set, caller waits till the context deadline.
+	MaxWait time.Duration
+}
+
+// Bulkhead holds the parameters for limiting the number of concurrent calls
+type Bulkhead struct {
+	// Maximum number of calls that can be in flight at a given time.
+	// If not set or set to 0, concurrency is unlimited.
+	MaxConcurrentRequests int
+	// Mode defines the behaviour once all the slots are taken. Default is LimitModeWait.
+	Mode LimitMode
+	// Maximum time to wait for a slot in LimitModeWait.
+	// If not set, caller waits till the context deadline.
+	MaxWait time.Duration
+}
+
+// Limits groups the rate limit and the bulkhead applied to a set of calls
+type Limits struct {
+	RateLimit *RateLimit
+	Bulkhead  *Bulkhead
+}
+
+// EndpointLimits holds the limits for a particular downstream endpoint
+type EndpointLimits struct {
+	// HTTP method of the endpoint. If empty, limits apply to all the methods on Path.
+	Method string
+	// URL path of the endpoint e.g. /v1/users
+	Path   string
+	Limits *Limits
+}
+
+type EndpointLimitsList []*EndpointLimits
+
+// GetEndpointLimitsMap returns the limits keyed by GetEndpointKey
+func (l EndpointLimitsList) GetEndpointLimitsMap() map[string]*EndpointLimits {
+	mp := map[string]*EndpointLimits{}
+
+	for _, el := range l {
+		mp[GetEndpointKey(el.Method, el.Path)] = el
+	}
+
+	return mp
+}
+
+// GetEndpointKey returns the key used to identify an endpoint for the given method and path
+func GetEndpointKey(method, path string) string {
+	return fmt.Sprintf("%s %s", method, path)
+}
+
+// RateLimitKeyType defines the attribute of the incoming request on which callers are rate limited
+type RateLimitKeyType string
+
+const (
+	// RateLimitKeyClientIP limits the callers by client IP
+	RateLimitKeyClientIP RateLimitKeyType = "CLIENT_IP"
+	// RateLimitKeyPrincipal limits the callers by the authenticated user, falls back to client IP for
+	// unauthenticated requests
+	RateLimitKeyPrincipal RateLimitKeyType = "PRINCIPAL"
+)
+
+// ServerRateLimit holds the parameters for rate limiting the incoming requests.
+// Inbound limits always fail fast, Mode and MaxWait of RateLimit are ignored.
+type ServerRateLimit struct {
+	// KeyType defines how the callers are identified. Default is RateLimitKeyClientIP.
+	KeyType RateLimitKeyType
+
+	// RateLimit applied per caller on every route which doesn't have an override.
+	// Optional: default is no limit
+	RateLimit *RateLimit
+
+	// RouteRateLimitsList overrides the rate limit for the particular routes
+	RouteRateLimitsList RouteRateLimitsList
+
+	// Duration after which the limiter of an inactive caller is evicted.
+	// If not set or set to 0, a default of 10 minutes will be used.
+	IdleKeyTTL time.Duration
+}
+
+// RouteRateLimit holds the rate limit override for a particular route
+type RouteRateLimit struct {
+	// HTTP method of the route. If empty, limit applies to all the methods on Path.
+	Method string
+	// Path of the route as registered in the router e.g. /v1/users/:id
+	Path      string
+	RateLimit *RateLimit
+}
+
+type RouteRateLimitsList []*RouteRateLimit
+
+// GetRouteRateLimitsMap returns the route limits keyed by GetEndpointKey
+func (l RouteRateLimitsList) GetRouteRateLimitsMap() map[string]*RouteRateLimit {
+	mp := map[string]*RouteRateLimit{}
+
+	for _, rl := range l {
+		mp[GetEndpointKey(rl.Method, rl.Path)] = rl
+	}
+
+	return mp
+}
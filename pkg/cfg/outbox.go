@@ -0,0 +1,32 @@
+package cfg
+
+import "time"
+
+type Outbox struct {
+	// interval between the polls of the relay when there are no pending events, 1s if not set
+	PollInterval time.Duration
+
+	// maximum number of events published in a poll, 100 if not set
+	BatchSize int
+
+	// Lease is how long the events claimed by a relay are hidden from the other relays, an event whose outcome is not
+	// recorded within the lease e.g. the relay crashed, is claimed again. It must exceed the time to publish a batch.
+	// Optional: default is 5m
+	Lease time.Duration
+
+	// RetryParams specify how the failed events are retried, the events are marked FAILED once the attempts are exhausted.
+	// Optional: default is exponential backoff from 1s to 5m for 10 attempts
+	RetryParams *RetryParams
+}
+
+// Webhook is an HTTP endpoint the events are POSTed to
+type Webhook struct {
+	URL string
+
+	// Secret signs the body of the request with HMAC-SHA256, sent as the hex encoded X-Signature-256 header.
+	// Optional: default is no signature
+	Secret string
+
+	// Headers are sent with every request e.g. Authorization
+	Headers map[string]string
+}
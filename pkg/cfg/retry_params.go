@@ -1,6 +1,8 @@
 package cfg
 
 import (
+	"math"
+	"math/rand"
 	"time"
 )
 
@@ -60,3 +62,84 @@ type Hybrid struct {
 	// Must be < MaxAttempts, for RetryStrategy2 to kick in
 	CutOff uint
 }
+
+// GetMaxAttempts returns the maximum number of retry attempts of the configured strategy, 0 if none is configured
+func (r *RetryParams) GetMaxAttempts() uint {
+	switch {
+	case r == nil:
+		return 0
+	case r.RegularInterval != nil:
+		return r.RegularInterval.MaxAttempts
+	case r.RegularIntervalWithJitter != nil:
+		return r.RegularIntervalWithJitter.MaxAttempts
+	case r.ExponentialBackOff != nil:
+		return r.ExponentialBackOff.MaxAttempts
+	case r.ExponentialBackOffWithJitter != nil:
+		return r.ExponentialBackOffWithJitter.MaxAttempts
+	case r.RandomizedInterval != nil:
+		return r.RandomizedInterval.MaxAttempts
+	case r.Hybrid != nil:
+		return r.Hybrid.MaxAttempts
+	default:
+		return 0
+	}
+}
+
+// GetBackoff returns the interval to wait before the retry attempt of the configured strategy, attempt starts at 1
+func (r *RetryParams) GetBackoff(attempt uint) time.Duration {
+	if attempt == 0 {
+		attempt = 1
+	}
+
+	switch {
+	case r == nil:
+		return 0
+	case r.RegularInterval != nil:
+		return r.RegularInterval.Interval
+	case r.RegularIntervalWithJitter != nil:
+		return withJitter(r.RegularIntervalWithJitter.Interval, r.RegularIntervalWithJitter.Jitter)
+	case r.ExponentialBackOff != nil:
+		b := r.ExponentialBackOff
+		return exponentialInterval(b.BaseInterval, b.MaxInterval, b.BackoffCoefficient, attempt)
+	case r.ExponentialBackOffWithJitter != nil:
+		b := r.ExponentialBackOffWithJitter
+		return withJitter(exponentialInterval(b.BaseInterval, b.MaxInterval, 0, attempt), b.Jitter)
+	case r.RandomizedInterval != nil:
+		b := r.RandomizedInterval
+		if b.MaxInterval <= b.MinInterval {
+			return b.MinInterval
+		}
+		return b.MinInterval + time.Duration(rand.Int63n(int64(b.MaxInterval-b.MinInterval)))
+	case r.Hybrid != nil:
+		if attempt <= r.Hybrid.CutOff {
+			return r.Hybrid.RetryStrategy1.GetBackoff(attempt)
+		}
+		return r.Hybrid.RetryStrategy2.GetBackoff(attempt - r.Hybrid.CutOff)
+	default:
+		return 0
+	}
+}
+
+func exponentialInterval(base, max time.Duration, coefficient float64, attempt uint) time.Duration {
+	if base == 0 {
+		base = time.Second
+	}
+	if coefficient <= 1 {
+		coefficient = 2
+	}
+
+	interval := time.Duration(float64(base) * math.Pow(coefficient, float64(attempt-1)))
+	if max > 0 && (interval > max || interval <= 0) {
+		return max
+	}
+	return interval
+}
+
+// withJitter randomizes the interval by ±jitter fraction of it
+func withJitter(interval time.Duration, jitter float64) time.Duration {
+	if jitter <= 0 {
+		return interval
+	}
+
+	return time.Duration(float64(interval) * (1 + jitter*(2*rand.Float64()-1)))
+}
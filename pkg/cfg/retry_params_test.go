@@ -0,0 +1,103 @@
+package cfg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryParams_GetBackoff(t *testing.T) {
+	t.Parallel()
+
+	hybrid := &RetryParams{Hybrid: &Hybrid{
+		RetryStrategy1: &RetryParams{RegularInterval: &RegularInterval{Interval: time.Second}},
+		RetryStrategy2: &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Minute, MaxInterval: 10 * time.Minute}},
+		MaxAttempts:    10,
+		CutOff:         2,
+	}}
+
+	tests := []struct {
+		name        string
+		params      *RetryParams
+		attempt     uint
+		min, max    time.Duration
+		maxAttempts uint
+	}{
+		{name: "nil", params: nil, attempt: 1, maxAttempts: 0},
+		{name: "none configured", params: &RetryParams{}, attempt: 1, maxAttempts: 0},
+		{
+			name:    "regular interval",
+			params:  &RetryParams{RegularInterval: &RegularInterval{Interval: 3 * time.Second, MaxAttempts: 5}},
+			attempt: 4, min: 3 * time.Second, max: 3 * time.Second, maxAttempts: 5,
+		},
+		{
+			name:    "regular interval with jitter",
+			params:  &RetryParams{RegularIntervalWithJitter: &RegularIntervalWithJitter{Interval: 10 * time.Second, Jitter: 0.2, MaxAttempts: 3}},
+			attempt: 2, min: 8 * time.Second, max: 12 * time.Second, maxAttempts: 3,
+		},
+		{
+			name:    "exponential first retry is the base interval",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Second, MaxAttempts: 4}},
+			attempt: 1, min: time.Second, max: time.Second, maxAttempts: 4,
+		},
+		{
+			name:    "exponential attempt 0 is the first retry",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Second}},
+			attempt: 0, min: time.Second, max: time.Second,
+		},
+		{
+			name:    "exponential with coefficient",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Second, BackoffCoefficient: 3}},
+			attempt: 3, min: 9 * time.Second, max: 9 * time.Second,
+		},
+		{
+			name:    "exponential defaults",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{}},
+			attempt: 4, min: 8 * time.Second, max: 8 * time.Second,
+		},
+		{
+			name:    "exponential capped",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Second, MaxInterval: 5 * time.Second}},
+			attempt: 10, min: 5 * time.Second, max: 5 * time.Second,
+		},
+		{
+			name:    "exponential capped on overflow",
+			params:  &RetryParams{ExponentialBackOff: &ExponentialBackOff{BaseInterval: time.Second, MaxInterval: time.Hour}},
+			attempt: 200, min: time.Hour, max: time.Hour,
+		},
+		{
+			name:    "exponential with jitter",
+			params:  &RetryParams{ExponentialBackOffWithJitter: &ExponentialBackOffWithJitter{BaseInterval: time.Second, Jitter: 0.5, MaxAttempts: 6}},
+			attempt: 3, min: 2 * time.Second, max: 6 * time.Second, maxAttempts: 6,
+		},
+		{
+			name:    "randomized interval",
+			params:  &RetryParams{RandomizedInterval: &RandomizedInterval{MinInterval: time.Second, MaxInterval: 2 * time.Second, MaxAttempts: 2}},
+			attempt: 1, min: time.Second, max: 2 * time.Second, maxAttempts: 2,
+		},
+		{
+			name:    "randomized interval without range",
+			params:  &RetryParams{RandomizedInterval: &RandomizedInterval{MinInterval: time.Second, MaxInterval: time.Second}},
+			attempt: 1, min: time.Second, max: time.Second,
+		},
+		{name: "hybrid before cut off", params: hybrid, attempt: 2, min: time.Second, max: time.Second, maxAttempts: 10},
+		{name: "hybrid after cut off", params: hybrid, attempt: 3, min: time.Minute, max: time.Minute, maxAttempts: 10},
+		{name: "hybrid second strategy grows", params: hybrid, attempt: 4, min: 2 * time.Minute, max: 2 * time.Minute, maxAttempts: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			a := require.New(t)
+
+			a.Equal(tt.maxAttempts, tt.params.GetMaxAttempts())
+			// randomized strategies are sampled a few times
+			for range 20 {
+				backoff := tt.params.GetBackoff(tt.attempt)
+				a.GreaterOrEqual(backoff, tt.min)
+				a.LessOrEqual(backoff, tt.max)
+			}
+		})
+	}
+}
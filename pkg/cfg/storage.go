@@ -22,25 +22,112 @@ type Storage struct {
 	MaxConnTtl time.Duration
 
 	GormV2Conf *GormV2Conf
+
+	// SessionConf is applied to every connection of the primary and the replicas
+	SessionConf *SessionConf
+
+	// Replicas serve the read only transactions and the queries marked with storage.FromReplica.
+	// Everything else is served by the primary described by PgDsn.
+	Replicas []*Replica
+
+	ReplicaRouting *ReplicaRouting
+}
+
+// Replica describes a read replica of the primary DB with its own pool settings
+type Replica struct {
+	PgDsn *PgDsn
+
+	// defines the maximum number of DB connections that can be opened to the replica
+	// If not set the default value is UNLIMITED.
+	MaxOpenConn int
+
+	// defines the maximum number of idle connection that can be present
+	// in the pool of the replica at a given time. The default value is 2 if not set
+	MaxIdleConn int
+
+	// defines the ttl for the replica connection.
+	MaxConnTtl time.Duration
+}
+
+// ReplicaPolicy defines how a replica is picked among the healthy ones
+type ReplicaPolicy string
+
+const (
+	RoundRobinReplicaPolicy       ReplicaPolicy = "ROUND_ROBIN"
+	LeastConnectionsReplicaPolicy ReplicaPolicy = "LEAST_CONNECTIONS"
+)
+
+type ReplicaRouting struct {
+	// Policy to pick a replica, ROUND_ROBIN if not set.
+	// LEAST_CONNECTIONS picks the replica with the least connections in use.
+	Policy ReplicaPolicy
+
+	// Replicas are pinged at HealthCheckInterval, a replica failing the ping is ejected until it passes again.
+	// Queries fall back to the primary if all the replicas are ejected.
+	// Health checks are disabled if not set.
+	HealthCheckInterval time.Duration
+
+	// Timeout of a single ping, 1s if not set
+	HealthCheckTimeout time.Duration
+
+	// A replica failing to begin a transaction or a ping is ejected for EjectionCooldown, after which it's tried again.
+	// 30s if not set
+	EjectionCooldown time.Duration
+}
+
+// SessionConf contains the run-time parameters set on the DB session of every connection.
+// Refer- https://www.postgresql.org/docs/current/runtime-config-client.html
+type SessionConf struct {
+	// aborts the statements taking more than the timeout, disabled if not set
+	StatementTimeout time.Duration
+	// aborts the statements waiting for a lock for more than the timeout, disabled if not set
+	LockTimeout time.Duration
+	// terminates the sessions idle in an open transaction for more than the timeout, disabled if not set
+	IdleInTransactionSessionTimeout time.Duration
+	// schemas searched in order for the unqualified names e.g. [app, public], default of the DB if not set
+	SearchPath []string
+	// any other run-time parameter e.g. timezone: UTC
+	RuntimeParams map[string]string
 }
 
 // PgDsn contains standard set of parameters needed to construct a postgres compatible DB connection DSN like AWS RDS
 // Refer- https://www.postgresql.org/docs/current/libpq-connect.html
 type PgDsn struct {
-	Host          string
-	Port          int
-	Username      string
-	Password      string
-	Name          string
-	SSLMode       string
-	SSLCertPath   string
-	SSLRootCert   string
+	Host string
+	// name of the env var overriding Host, DB_HOST if not set for the primary
+	HostEnv  string
+	Port     int
+	Username string
+	Password string
+	// UsernameSource and PasswordSource are resolved for every new connection so that the rotated
+	// credentials are picked without restarting the pool. Username and Password are used if not set.
+	UsernameSource *SecretSource
+	PasswordSource *SecretSource
+	Name           string
+	SSLMode        string
+	// directory of the cert files, relative SSLRootCert, SSLClientCert and SSLClientKey are resolved against it
+	SSLCertPath string
+	// CA certificate file used to verify the server for verify-ca and verify-full SSLMode
+	SSLRootCert string
+	// client certificate and key files used to authenticate with the server
 	SSLClientCert string
 	SSLClientKey  string
 	// application name to be set in DB session connection
 	AppName string
 }
 
+// SecretSource describes where a secret is read from, exactly one of Env, File and Command must be set
+type SecretSource struct {
+	// name of the env var having the secret
+	Env string
+	// path of the file having the secret e.g. a mounted kubernetes secret
+	File string
+	// command printing the secret on stdout e.g. [aws, rds, generate-db-auth-token, ...]
+	Command []string
+	// the resolved secret is reused for CacheTtl, it's resolved every time if not set
+	CacheTtl time.Duration
+}
+
 type GormV2Conf struct {
 	// LogLevelGormV2 will correspond to the levels defined in this doc https://pkg.go.dev/gorm.io/gorm/logger#LogLevel
 	LogLevelGormV2 GormLogLevel
@@ -0,0 +1,281 @@
+package errors
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+
+	"github.com/pkg/errors"
+	temporalsdk "go.temporal.io/sdk/temporal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/protoadapt"
+)
+
+// Details are the field level details of an Error e.g. field→reason
+type Details map[string]string
+
+// Retryable marks an Error as retryable or not
+type Retryable bool
+
+// Error is a typed domain error built by E
+type Error struct {
+	Type ErrorType
+	// Message is safe to be shown to the user
+	Message string
+	// Cause is the underlying error, only exposed as the debug message
+	Cause     error
+	Details   Details
+	Retryable bool
+	stack     errors.StackTrace
+}
+
+// E builds an *Error from the arguments, the meaning of an argument depends on its type:
+//   - ErrorType is the type of the error
+//   - string is the user facing message
+//   - error is the cause
+//   - Details or map[string]string are merged into the details
+//   - Retryable marks the error as retryable or not
+//
+// Arguments of any other type are a programming error, they are reported in the cause without dropping the rest.
+// If the type is not passed, it's inherited from the *Error in the cause chain, or the registered type matching
+// the cause e.g. ErrRecordNotFoundStr for ErrRecordNotFound, ErrInternalServerStr otherwise.
+// If retryability is not passed, the error is retryable if the cause is ErrTransient, not if the cause is ErrPermanent,
+// retryable if the type is not passed and the cause matches no registered type, and the default of the type otherwise.
+// Stack trace is captured at the call site.
+//
+//	errors.E(errors.ErrRecordNotFoundStr, "user not found", err, errors.Details{"id": id})
+func E(args ...any) error {
+	e := &Error{}
+	var retryable *bool
+	var unknown []any
+	for _, arg := range args {
+		switch a := arg.(type) {
+		case ErrorType:
+			e.Type = a
+		case string:
+			e.Message = a
+		case Details:
+			e.addDetails(a)
+		case map[string]string:
+			e.addDetails(a)
+		case Retryable:
+			r := bool(a)
+			retryable = &r
+		case error:
+			e.Cause = a
+		default:
+			unknown = append(unknown, arg)
+		}
+	}
+
+	inner := &Error{}
+	hasInner := e.Cause != nil && As(e.Cause, &inner)
+	if hasInner && len(inner.Details) > 0 {
+		details := make(Details, len(inner.Details)+len(e.Details))
+		maps.Copy(details, inner.Details)
+		maps.Copy(details, e.Details)
+		e.Details = details
+	}
+
+	// unclassified is set for the cause matching no registered type e.g. a network blip
+	var unclassified bool
+	switch {
+	case e.Type != "":
+	case hasInner:
+		e.Type = inner.Type
+	case e.Cause != nil:
+		info, ok := getMatchingErrorTypeInfo(e.Cause)
+		e.Type, unclassified = info.ErrorType, !ok
+	default:
+		e.Type = ErrInternalServerStr
+	}
+
+	switch {
+	case retryable != nil:
+		e.Retryable = *retryable
+	case e.Cause != nil && Is(e.Cause, ErrTransient):
+		e.Retryable = true
+	case e.Cause != nil && Is(e.Cause, ErrPermanent):
+		e.Retryable = false
+	case unclassified:
+		e.Retryable = true
+	default:
+		info, _ := GetErrorTypeInfo(e.Type)
+		e.Retryable = info.Retryable
+	}
+
+	for _, arg := range unknown {
+		if e.Cause == nil {
+			e.Cause = New("errors.E: unknown argument of type %T: %v", arg, arg)
+			continue
+		}
+		e.Cause = Wrap(e.Cause, "errors.E: unknown argument of type %T: %v", arg, arg)
+	}
+
+	e.stack = callers()
+	return e
+}
+
+func (e *Error) addDetails(details map[string]string) {
+	if e.Details == nil {
+		e.Details = Details{}
+	}
+	maps.Copy(e.Details, details)
+}
+
+func (e *Error) Error() string {
+	parts := []string{string(e.Type)}
+	if e.Message != "" {
+		parts = append(parts, e.Message)
+	}
+	if e.Cause != nil {
+		parts = append(parts, e.Cause.Error())
+	}
+
+	return strings.Join(parts, ": ")
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is reports whether the error matches the sentinel of its type, ErrTransient if retryable and ErrPermanent if not
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrTransient:
+		return e.Retryable
+	case ErrPermanent:
+		return !e.Retryable
+	}
+
+	info, ok := GetErrorTypeInfo(e.Type)
+	return ok && info.Sentinel != nil && info.Sentinel == target
+}
+
+// StackTrace returns the stack trace captured by E
+func (e *Error) StackTrace() errors.StackTrace {
+	return e.stack
+}
+
+// Format prints the stack trace for %+v like the errors of github.com/pkg/errors
+func (e *Error) Format(s fmt.State, verb rune) {
+	switch verb {
+	case 'v':
+		if s.Flag('+') {
+			if e.Cause != nil {
+				_, _ = fmt.Fprintf(s, "%+v\n", e.Cause)
+			}
+			_, _ = io.WriteString(s, e.Error())
+			e.stack.Format(s, verb)
+			return
+		}
+		fallthrough
+	case 's':
+		_, _ = io.WriteString(s, e.Error())
+	case 'q':
+		_, _ = fmt.Fprintf(s, "%q", e.Error())
+	}
+}
+
+// ErrorResponse converts the error into the ErrorResponse answered over HTTP
+func (e *Error) ErrorResponse() ErrorResponse {
+	message := e.Message
+	if message == "" {
+		info, _ := GetErrorTypeInfo(e.Type)
+		message = info.Message
+	}
+
+	errResp := NewErrorResponseWithDebug(message, "", e.Type)
+	if e.Cause != nil {
+		errResp.DebugMessage = e.Cause.Error()
+	}
+	errResp.ErrorDetails = maps.Clone(e.Details)
+	errResp.Retryable = e.Retryable
+	return errResp
+}
+
+// ApplicationError converts the error into a Temporal ApplicationError of the same type, the details
+// are passed as the only detail of the ApplicationError
+func (e *Error) ApplicationError() error {
+	opts := temporalsdk.ApplicationErrorOptions{
+		NonRetryable: !e.Retryable,
+		Cause:        e.Cause,
+	}
+	if len(e.Details) > 0 {
+		opts.Details = []any{map[string]string(e.Details)}
+	}
+
+	return temporalsdk.NewApplicationErrorWithOptions(e.Message, string(e.Type), opts)
+}
+
+// GRPCStatus converts the error into a gRPC status with the code registered for the type.
+// Type and details are carried by an ErrorInfo, the cause by a DebugInfo and a retryable error has a RetryInfo.
+// It makes status.FromError and status.Code work with the error, FromGRPCStatus converts it back.
+func (e *Error) GRPCStatus() *status.Status {
+	info, _ := GetErrorTypeInfo(e.Type)
+	code := info.GrpcCode
+	if code == codes.OK {
+		code = codes.Unknown
+	}
+
+	errResp := e.ErrorResponse()
+	st := status.New(code, errResp.Message)
+	statusDetails := []protoadapt.MessageV1{&errdetails.ErrorInfo{Reason: string(e.Type), Metadata: e.Details}}
+	if e.Cause != nil {
+		statusDetails = append(statusDetails, &errdetails.DebugInfo{Detail: errResp.DebugMessage})
+	}
+	if e.Retryable {
+		statusDetails = append(statusDetails, &errdetails.RetryInfo{})
+	}
+
+	stWithDetails, err := st.WithDetails(statusDetails...)
+	if err != nil {
+		return st
+	}
+	return stWithDetails
+}
+
+// FromGRPCStatus converts a status created by Error.GRPCStatus back into an *Error at the client side, so that
+// errors.Is keeps working with the sentinels and ErrTransient. Type, details and retryability are read from
+// the details of the status, a status without ErrorInfo is of the first type registered with its code.
+// The status error is kept as the cause, nil is returned for a nil or OK status.
+func FromGRPCStatus(st *status.Status) error {
+	if st == nil || st.Code() == codes.OK {
+		return nil
+	}
+
+	args := []any{st.Message(), st.Err()}
+	var errInfo *errdetails.ErrorInfo
+	var retryable bool
+	for _, detail := range st.Details() {
+		switch d := detail.(type) {
+		case *errdetails.ErrorInfo:
+			errInfo = d
+		case *errdetails.RetryInfo:
+			retryable = true
+		}
+	}
+
+	if errInfo == nil {
+		return E(append(args, getErrorTypeFromGrpcCode(st.Code()))...)
+	}
+
+	args = append(args, ErrorType(errInfo.GetReason()), Retryable(retryable))
+	if len(errInfo.GetMetadata()) > 0 {
+		args = append(args, Details(errInfo.GetMetadata()))
+	}
+	return E(args...)
+}
+
+// callers captures the stack trace of the caller of E
+func callers() errors.StackTrace {
+	st := errors.New("").(interface{ StackTrace() errors.StackTrace }).StackTrace()
+	// skip callers and E
+	if len(st) > 2 {
+		return st[2:]
+	}
+	return st
+}
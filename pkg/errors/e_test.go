@@ -0,0 +1,127 @@
+package errors
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	temporalsdk "go.temporal.io/sdk/temporal"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestE(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	cause := Wrap(ErrRecordNotFound, "user %s", "u1")
+	err := E("user not found", cause, Details{"id": "u1"})
+	a.ErrorIs(err, ErrRecordNotFound)
+	a.Equal("record not found: user not found: user u1: record not found", err.Error())
+	a.Contains(fmt.Sprintf("%+v", err), "TestE")
+
+	e := &Error{}
+	a.ErrorAs(err, &e)
+	a.Equal(ErrRecordNotFoundStr, e.Type)
+	a.False(e.Retryable)
+
+	a.Equal(ErrorResponse{
+		Message:      "user not found",
+		DebugMessage: cause.Error(),
+		Code:         errCodeRecordNotFound,
+		ErrorType:    ErrRecordNotFoundStr,
+		ErrorDetails: map[string]string{"id": "u1"},
+	}, ToErrorResponse(Wrap(err, "get user")))
+
+	st := status.Convert(err)
+	a.Equal(codes.NotFound, st.Code())
+	a.Equal("user not found", st.Message())
+	a.Len(st.Details(), 2)
+	a.Equal(map[string]string{"id": "u1"}, st.Details()[0].(*errdetails.ErrorInfo).Metadata)
+
+	appErr := &temporalsdk.ApplicationError{}
+	a.ErrorAs(e.ApplicationError(), &appErr)
+	a.Equal(string(ErrRecordNotFoundStr), appErr.Type())
+	a.True(appErr.NonRetryable())
+	var details map[string]string
+	a.NoError(appErr.Details(&details))
+	a.Equal(map[string]string{"id": "u1"}, details)
+
+	// type and details are inherited from the cause
+	err = E(Wrap(ErrTransient, "db down"), ErrResourceExhaustedStr, Details{"pool": "primary"})
+	err = E("try again later", err, Details{"op": "save"})
+	a.ErrorAs(err, &e)
+	a.Equal(ErrResourceExhaustedStr, e.Type)
+	a.True(e.Retryable)
+	a.ErrorIs(err, ErrTransient)
+	a.ErrorIs(err, ErrResourceExhausted)
+	a.Equal(Details{"pool": "primary", "op": "save"}, e.Details)
+
+	// errors without a cause are internal and not retryable
+	err = E("unexpected state")
+	a.ErrorAs(err, &e)
+	a.Equal(ErrInternalServerStr, e.Type)
+	a.ErrorIs(err, ErrPermanent)
+	a.NotErrorIs(err, ErrTransient)
+
+	// causes matching no registered type are internal but stay retryable
+	err = E("lookup failed", New("connection reset by peer"))
+	a.ErrorAs(err, &e)
+	a.Equal(ErrInternalServerStr, e.Type)
+	a.ErrorIs(err, ErrTransient)
+
+	// details of the cause without details
+	err = E("lookup failed", E(ErrRecordNotFoundStr, "user missing"), Details{"user_id": "42"})
+	a.ErrorAs(err, &e)
+	a.Equal(Details{"user_id": "42"}, e.Details)
+	a.ErrorIs(err, ErrRecordNotFound)
+
+	// unknown arguments keep the type and the cause
+	err = E(ErrInvalidArgumentStr, Retryable(false), 42)
+	a.Contains(err.Error(), "unknown argument of type int")
+	a.ErrorIs(err, ErrInvalidArgument)
+	err = E(ErrInvalidArgumentStr, 42, cause)
+	a.ErrorIs(err, ErrInvalidArgument)
+	a.ErrorIs(err, ErrRecordNotFound)
+	a.Contains(err.Error(), "unknown argument of type int")
+}
+
+func TestGRPCStatusConversion(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	for _, tc := range []struct {
+		err       error
+		errType   ErrorType
+		retryable bool
+	}{
+		{err: E(ErrRecordNotFoundStr, "user not found", Details{"id": "u1"}), errType: ErrRecordNotFoundStr},
+		{err: E(ErrResourceExhaustedStr, "quota"), errType: ErrResourceExhaustedStr, retryable: true},
+		{err: E(ErrInvalidArgumentStr, "retry with another name", Retryable(true)), errType: ErrInvalidArgumentStr, retryable: true},
+		{err: E("lookup failed", New("connection reset by peer")), errType: ErrInternalServerStr, retryable: true},
+		{err: E(ErrTransientStr, "db down", Retryable(false)), errType: ErrTransientStr},
+	} {
+		e := &Error{}
+		a.ErrorAs(FromGRPCStatus(status.Convert(tc.err)), &e, tc.errType)
+		a.Equal(tc.errType, e.Type)
+		a.Equal(tc.retryable, e.Retryable, tc.errType)
+		a.Equal(tc.retryable, Is(e, ErrTransient), tc.errType)
+	}
+
+	e := &Error{}
+	err := FromGRPCStatus(status.Convert(E(ErrRecordNotFoundStr, "user not found", Details{"id": "u1"})))
+	a.ErrorAs(err, &e)
+	a.ErrorIs(err, ErrRecordNotFound)
+	a.Equal("user not found", e.Message)
+	a.Equal(Details{"id": "u1"}, e.Details)
+
+	// statuses of other servers are typed by the code
+	a.ErrorIs(FromGRPCStatus(status.New(codes.NotFound, "no such user")), ErrRecordNotFound)
+	a.ErrorIs(FromGRPCStatus(status.New(codes.Unavailable, "connection refused")), ErrTransient)
+	a.ErrorAs(FromGRPCStatus(status.New(codes.DataLoss, "corrupt")), &e)
+	a.Equal(ErrInternalServerStr, e.Type)
+	a.False(e.Retryable)
+	a.Nil(FromGRPCStatus(status.New(codes.OK, "")))
+	a.Nil(FromGRPCStatus(nil))
+}
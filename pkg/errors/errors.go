@@ -3,7 +3,6 @@ package errors
 
 import (
 	"fmt"
-	"strings"
 
 	"github.com/pkg/errors"
 	temporalsdk "go.temporal.io/sdk/temporal"
@@ -28,6 +27,10 @@ const (
 	ErrFailedPreconditionStr         ErrorType = "failed precondition"
 	ErrActivityRateLimitExhaustedStr ErrorType = "Activity Rate Limit Exhausted"
 	ErrResourceExhaustedStr          ErrorType = "resource exhausted"
+	ErrTimedOutStr                   ErrorType = "timed out"
+	ErrRequestCanceledStr            ErrorType = "request canceled"
+	ErrTransientStr                  ErrorType = "transient failure"
+	ErrPermanentStr                  ErrorType = "permanent failure"
 )
 
 var (
@@ -139,8 +142,14 @@ func IsRecordNotFound(err error) bool {
 	return Is(err, gorm.ErrRecordNotFound) || Is(err, ErrRecordNotFound)
 }
 
+// IsDuplicateKeyConstraintErr checks whether the error is ErrAlreadyExists.
+// Postgres errors are classified as ErrAlreadyExists by storage.ClassifyError.
 func IsDuplicateKeyConstraintErr(err error) bool {
-	return Is(err, ErrAlreadyExists) || strings.EqualFold(err.Error(), "duplicate key value violates unique constraint")
+	if err == nil {
+		return false
+	}
+
+	return Is(err, ErrAlreadyExists) || Is(err, gorm.ErrDuplicatedKey)
 }
 
 // IsErrorOfType checks whether an error is of particular type or not
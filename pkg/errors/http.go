@@ -16,6 +16,12 @@ const (
 	errCodePermissionDenied
 	errFailedPrecondition
 	errResourceExhausted
+	errInProgress
+	errTimedOut
+	errRequestCanceled
+	errActivityRateLimitExhausted
+	errTransient
+	errPermanent
 )
 
 // ErrorResponse represents a generic error response structure
@@ -25,6 +31,7 @@ type ErrorResponse struct {
 	Code         int               `json:"code"`
 	ErrorType    ErrorType         `json:"error_type"`
 	ErrorDetails map[string]string `json:"error_details,omitempty"`
+	Retryable    bool              `json:"retryable,omitempty"`
 }
 
 func (e ErrorResponse) Error() string {
@@ -60,71 +67,35 @@ func NewErrorResponseWithDebug(msg string, debug string, errType ErrorType) Erro
 	}
 }
 
+// GetHttpCodeFromErrorType returns the HTTP status of the registered error type, 500 if unknown
 func GetHttpCodeFromErrorType(errType ErrorType) int {
-	switch errType {
-	case ErrInvalidArgumentStr:
-		return http.StatusBadRequest
-	case ErrRecordNotFoundStr:
-		return http.StatusOK
-	case ErrAlreadyExistsStr:
-		return http.StatusAlreadyReported
-	case ErrInternalServerStr:
-		return http.StatusInternalServerError
-	case ErrBadRequestStr:
-		return http.StatusBadRequest
-	case ErrPermissionDeniedStr:
-		return http.StatusForbidden
-	case ErrFailedPreconditionStr:
-		return http.StatusPreconditionFailed
-	case ErrResourceExhaustedStr:
-		return http.StatusTooManyRequests
-	default:
+	info, ok := GetErrorTypeInfo(errType)
+	if !ok {
 		return http.StatusInternalServerError
 	}
+
+	return info.HttpStatus
 }
 
+// GetErrorCodeForErrorType returns the code of the registered error type, errCodeUnknown if unknown
 func GetErrorCodeForErrorType(errType ErrorType) int {
-	switch errType {
-	case ErrInvalidArgumentStr:
-		return errCodeInvalidArgument
-	case ErrRecordNotFoundStr:
-		return errCodeRecordNotFound
-	case ErrAlreadyExistsStr:
-		return errCodeAlreadyExists
-	case ErrInternalServerStr:
-		return errCodeInternalServer
-	case ErrBadRequestStr:
-		return errCodeBadRequest
-	case ErrPermissionDeniedStr:
-		return errCodePermissionDenied
-	case ErrFailedPreconditionStr:
-		return errFailedPrecondition
-	case ErrResourceExhaustedStr:
-		return errResourceExhausted
-	default:
+	info, ok := GetErrorTypeInfo(errType)
+	if !ok {
 		return errCodeUnknown
 	}
+
+	return info.Code
 }
 
+// GetErrorTypeFromErrorCode returns the error type registered with the code.
+// For the backward compatibility, HTTP statuses are accepted as well, see GetErrorTypeFromHttpStatus.
 func GetErrorTypeFromErrorCode(code int) ErrorType {
-	switch code {
-	case http.StatusBadRequest, errCodeBadRequest:
-		return ErrBadRequestStr
-	case errCodeInvalidArgument:
-		return ErrInvalidArgumentStr
-	case http.StatusNotFound, errCodeRecordNotFound:
-		return ErrRecordNotFoundStr
-	case http.StatusAlreadyReported, errCodeAlreadyExists:
-		return ErrAlreadyExistsStr
-	case http.StatusInternalServerError, errCodeInternalServer:
-		return ErrInternalServerStr
-	case errCodePermissionDenied:
-		return ErrPermissionDeniedStr
-	case errFailedPrecondition:
-		return ErrFailedPreconditionStr
-	case errResourceExhausted:
-		return ErrResourceExhaustedStr
-	default:
-		return ErrInternalServerStr
+	registry.mu.RLock()
+	errType, ok := registry.codes[code]
+	registry.mu.RUnlock()
+	if ok {
+		return errType
 	}
+
+	return GetErrorTypeFromHttpStatus(code)
 }
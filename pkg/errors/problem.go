@@ -0,0 +1,163 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// ContentTypeProblemJSON is the media type of the RFC 7807 problem details
+	ContentTypeProblemJSON = "application/problem+json"
+
+	problemErrorTypeMember = "error_type"
+	problemCodeMember      = "code"
+	problemRetryableMember = "retryable"
+)
+
+// ProblemTypeURIFn returns the type member of the problem details of the error type.
+// Services can replace it to point to the documentation of their errors.
+var ProblemTypeURIFn = func(errType ErrorType) string {
+	return "about:blank"
+}
+
+// ProblemDetails is the RFC 7807 representation of an ErrorResponse.
+// ErrorType, Code and Retryable are carried by the error_type, code and retryable extension members,
+// ErrorDetails by the rest of them.
+type ProblemDetails struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Extensions map[string]any
+}
+
+// NewProblemDetails converts the error response into problem details, instance identifies the failed request
+func NewProblemDetails(errResp ErrorResponse, status int, instance string) ProblemDetails {
+	extensions := make(map[string]any, len(errResp.ErrorDetails)+2)
+	for k, v := range errResp.ErrorDetails {
+		extensions[k] = v
+	}
+	extensions[problemErrorTypeMember] = errResp.ErrorType
+	extensions[problemCodeMember] = errResp.Code
+	if errResp.Retryable {
+		extensions[problemRetryableMember] = true
+	}
+
+	return ProblemDetails{
+		Type:       ProblemTypeURIFn(errResp.ErrorType),
+		Title:      errResp.Message,
+		Status:     status,
+		Detail:     errResp.DebugMessage,
+		Instance:   instance,
+		Extensions: extensions,
+	}
+}
+
+// ToErrorResponse converts the problem details back into an error response. If the error_type member is missing,
+// e.g. problem details of a third party, the type is derived from the status.
+func (p ProblemDetails) ToErrorResponse() ErrorResponse {
+	errResp := ErrorResponse{
+		Message:      p.Title,
+		DebugMessage: p.Detail,
+		ErrorType:    GetErrorTypeFromHttpStatus(p.Status),
+	}
+
+	for k, v := range p.Extensions {
+		switch k {
+		case problemErrorTypeMember:
+			if errType, ok := v.(string); ok && errType != "" {
+				errResp.ErrorType = ErrorType(errType)
+			}
+		case problemCodeMember:
+			if code, ok := v.(float64); ok {
+				errResp.Code = int(code)
+			}
+		case problemRetryableMember:
+			errResp.Retryable, _ = v.(bool)
+		default:
+			if errResp.ErrorDetails == nil {
+				errResp.ErrorDetails = map[string]string{}
+			}
+			errResp.ErrorDetails[k] = formatProblemMember(v)
+		}
+	}
+
+	if _, ok := p.Extensions[problemCodeMember]; !ok {
+		errResp.Code = GetErrorCodeForErrorType(errResp.ErrorType)
+	}
+	if errResp.Message == "" {
+		errResp.Message = p.Type
+	}
+
+	return errResp
+}
+
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		m[k] = v
+	}
+
+	// standard members take precedence over the extensions
+	for k, v := range map[string]any{"type": p.Type, "title": p.Title, "detail": p.Detail, "instance": p.Instance} {
+		if v != "" {
+			m[k] = v
+		} else {
+			delete(m, k)
+		}
+	}
+	delete(m, "status")
+	if p.Status != 0 {
+		m["status"] = p.Status
+	}
+
+	return json.Marshal(m)
+}
+
+func (p *ProblemDetails) UnmarshalJSON(b []byte) error {
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	*p = ProblemDetails{}
+	for k, v := range m {
+		switch k {
+		case "type":
+			p.Type, _ = v.(string)
+		case "title":
+			p.Title, _ = v.(string)
+		case "detail":
+			p.Detail, _ = v.(string)
+		case "instance":
+			p.Instance, _ = v.(string)
+		case "status":
+			if status, ok := v.(float64); ok {
+				p.Status = int(status)
+			}
+		default:
+			if p.Extensions == nil {
+				p.Extensions = map[string]any{}
+			}
+			p.Extensions[k] = v
+		}
+	}
+
+	return nil
+}
+
+func formatProblemMember(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case nil:
+		return ""
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
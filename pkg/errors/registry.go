@@ -0,0 +1,274 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	// CustomErrorCodeStart is the first code available to the error types registered by the services
+	CustomErrorCodeStart = 1000
+	// StatusClientClosedRequest is the non standard status used when the client cancels the request
+	StatusClientClosedRequest = 499
+)
+
+// ErrorTypeInfo describes how an ErrorType is represented in the ErrorResponse, over HTTP and gRPC
+type ErrorTypeInfo struct {
+	ErrorType ErrorType
+	// Code set in ErrorResponse.Code, unique across the types
+	Code       int
+	HttpStatus int
+	// GrpcCode of the status created by Error.GRPCStatus, codes.Unknown if not set
+	GrpcCode codes.Code
+	// Message is the user facing message of the ErrorResponse created by ToErrorResponse
+	Message string
+	// Retryable is the default retryability of the errors of the type
+	Retryable bool
+	// Sentinel of the type e.g. ErrRecordNotFound, errors matching it are of the type, optional
+	Sentinel error
+	// Match reports whether a plain error is of the type in addition to the Sentinel, optional
+	Match func(err error) bool
+}
+
+type errorTypeRegistry struct {
+	mu sync.RWMutex
+	// types in the order of registration, the first matching type wins
+	types        []ErrorType
+	infos        map[ErrorType]ErrorTypeInfo
+	codes        map[int]ErrorType
+	httpStatuses map[int]ErrorType
+}
+
+var registry = newErrorTypeRegistry(
+	ErrorTypeInfo{
+		ErrorType: ErrInvalidArgumentStr, Code: errCodeInvalidArgument, HttpStatus: http.StatusBadRequest, GrpcCode: codes.InvalidArgument,
+		Message: "Invalid Argument", Sentinel: ErrInvalidArgument,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrRecordNotFoundStr, Code: errCodeRecordNotFound, HttpStatus: http.StatusNotFound, GrpcCode: codes.NotFound,
+		Message: "Record Not Found", Sentinel: ErrRecordNotFound, Match: IsRecordNotFound,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrAlreadyExistsStr, Code: errCodeAlreadyExists, HttpStatus: http.StatusConflict, GrpcCode: codes.AlreadyExists,
+		Message: "Already Exists", Sentinel: ErrAlreadyExists,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrInternalServerStr, Code: errCodeInternalServer, HttpStatus: http.StatusInternalServerError, GrpcCode: codes.Internal,
+		Message: "Internal Server Error",
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrBadRequestStr, Code: errCodeBadRequest, HttpStatus: http.StatusBadRequest, GrpcCode: codes.InvalidArgument,
+		Message: "Bad Request",
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrPermissionDeniedStr, Code: errCodePermissionDenied, HttpStatus: http.StatusForbidden, GrpcCode: codes.PermissionDenied,
+		Message: "Permission Denied", Sentinel: ErrPermissionDenied,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrFailedPreconditionStr, Code: errFailedPrecondition, HttpStatus: http.StatusPreconditionFailed, GrpcCode: codes.FailedPrecondition,
+		Message: "Failed Precondition", Sentinel: ErrFailedPrecondition,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrResourceExhaustedStr, Code: errResourceExhausted, HttpStatus: http.StatusTooManyRequests, GrpcCode: codes.ResourceExhausted,
+		Retryable: true, Message: "Resource Exhausted", Sentinel: ErrResourceExhausted,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrInProgressStr, Code: errInProgress, HttpStatus: http.StatusConflict, GrpcCode: codes.Aborted,
+		Retryable: true, Message: "In Progress",
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrTimedOutStr, Code: errTimedOut, HttpStatus: http.StatusGatewayTimeout, GrpcCode: codes.DeadlineExceeded,
+		Retryable: true, Message: "Timed Out", Sentinel: ErrTimedOut, Match: isFn(context.DeadlineExceeded),
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrRequestCanceledStr, Code: errRequestCanceled, HttpStatus: StatusClientClosedRequest, GrpcCode: codes.Canceled,
+		Message: "Request Canceled", Sentinel: ErrRequestCanceled, Match: isFn(context.Canceled),
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrActivityRateLimitExhaustedStr, Code: errActivityRateLimitExhausted, HttpStatus: http.StatusTooManyRequests, GrpcCode: codes.ResourceExhausted,
+		Retryable: true, Message: "Activity Rate Limit Exhausted",
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrTransientStr, Code: errTransient, HttpStatus: http.StatusServiceUnavailable, GrpcCode: codes.Unavailable,
+		Retryable: true, Message: "Service Unavailable", Sentinel: ErrTransient,
+	},
+	ErrorTypeInfo{
+		ErrorType: ErrPermanentStr, Code: errPermanent, HttpStatus: http.StatusInternalServerError, GrpcCode: codes.Internal,
+		Message: "Internal Server Error", Sentinel: ErrPermanent,
+	},
+)
+
+func newErrorTypeRegistry(infos ...ErrorTypeInfo) *errorTypeRegistry {
+	r := &errorTypeRegistry{
+		infos: map[ErrorType]ErrorTypeInfo{},
+		codes: map[int]ErrorType{},
+	}
+	for _, info := range infos {
+		r.add(info)
+	}
+	r.indexHttpStatuses()
+
+	return r
+}
+
+func (r *errorTypeRegistry) add(info ErrorTypeInfo) {
+	r.types = append(r.types, info.ErrorType)
+	r.infos[info.ErrorType] = info
+	r.codes[info.Code] = info.ErrorType
+}
+
+// indexHttpStatuses maps every status to the first type registered with it
+func (r *errorTypeRegistry) indexHttpStatuses() {
+	r.httpStatuses = map[int]ErrorType{}
+	for _, errType := range r.types {
+		status := r.infos[errType].HttpStatus
+		if _, ok := r.httpStatuses[status]; !ok {
+			r.httpStatuses[status] = errType
+		}
+	}
+}
+
+// RegisterErrorType registers a domain error type. Code must be unique and not less than CustomErrorCodeStart.
+// Must be called during the initialisation of the service, on both the server and the client side,
+// so that both agree on the code and the HTTP status of the type.
+func RegisterErrorType(info ErrorTypeInfo) error {
+	if info.ErrorType == "" {
+		return fmt.Errorf("empty error type: %w", ErrInvalidArgument)
+	}
+	if info.Code < CustomErrorCodeStart {
+		return fmt.Errorf("code %d of %s is less than %d: %w", info.Code, info.ErrorType, CustomErrorCodeStart, ErrInvalidArgument)
+	}
+	if http.StatusText(info.HttpStatus) == "" && info.HttpStatus != StatusClientClosedRequest {
+		return fmt.Errorf("invalid http status %d of %s: %w", info.HttpStatus, info.ErrorType, ErrInvalidArgument)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	if _, ok := registry.infos[info.ErrorType]; ok {
+		return fmt.Errorf("error type %s: %w", info.ErrorType, ErrAlreadyExists)
+	}
+	if errType, ok := registry.codes[info.Code]; ok {
+		return fmt.Errorf("code %d already used by %s: %w", info.Code, errType, ErrAlreadyExists)
+	}
+
+	registry.add(info)
+	registry.indexHttpStatuses()
+	return nil
+}
+
+// SetHttpStatusForErrorType overrides the HTTP status of a registered error type
+func SetHttpStatusForErrorType(errType ErrorType, httpStatus int) error {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+	info, ok := registry.infos[errType]
+	if !ok {
+		return fmt.Errorf("error type %s: %w", errType, ErrRecordNotFound)
+	}
+
+	info.HttpStatus = httpStatus
+	registry.infos[errType] = info
+	registry.indexHttpStatuses()
+	return nil
+}
+
+// GetErrorTypeInfo returns the registered info of the error type
+func GetErrorTypeInfo(errType ErrorType) (ErrorTypeInfo, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	info, ok := registry.infos[errType]
+	return info, ok
+}
+
+// GetErrorTypeFromHttpStatus returns the first type registered with the status.
+// Unknown 4xx statuses are ErrBadRequestStr and the rest ErrInternalServerStr.
+func GetErrorTypeFromHttpStatus(status int) ErrorType {
+	registry.mu.RLock()
+	errType, ok := registry.httpStatuses[status]
+	registry.mu.RUnlock()
+	switch {
+	case ok:
+		return errType
+	case status >= http.StatusBadRequest && status < http.StatusInternalServerError:
+		return ErrBadRequestStr
+	default:
+		return ErrInternalServerStr
+	}
+}
+
+// getErrorTypeFromGrpcCode returns the first type registered with the code, ErrInternalServerStr if none
+func getErrorTypeFromGrpcCode(code codes.Code) ErrorType {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, errType := range registry.types {
+		if registry.infos[errType].GrpcCode == code {
+			return errType
+		}
+	}
+
+	return ErrInternalServerStr
+}
+
+// ToErrorResponse converts the error into an ErrorResponse. *Error or ErrorResponse in the chain is converted as is,
+// otherwise the type is the first registered type matching the error, ErrInternalServerStr if none.
+// ErrorResponse without type is of the type of its code, ErrInternalServerStr if the code is not set either.
+func ToErrorResponse(err error) ErrorResponse {
+	errResp := ErrorResponse{}
+	errRespPtr := &ErrorResponse{}
+	e := &Error{}
+	switch {
+	case As(err, &e):
+		return e.ErrorResponse()
+	case As(err, &errResp):
+	case As(err, &errRespPtr):
+		errResp = *errRespPtr
+	default:
+		info, _ := getMatchingErrorTypeInfo(err)
+		return NewErrorResponseWithDebug(info.Message, err.Error(), info.ErrorType)
+	}
+
+	switch {
+	case errResp.ErrorType != "":
+	case errResp.Code == 0:
+		// zero code is not set, rather than the code of the first registered type
+		errResp.ErrorType = ErrInternalServerStr
+	default:
+		errResp.ErrorType = GetErrorTypeFromErrorCode(errResp.Code)
+	}
+	if errResp.Code == 0 {
+		errResp.Code = GetErrorCodeForErrorType(errResp.ErrorType)
+	}
+	return errResp
+}
+
+// getMatchingErrorTypeInfo returns the first registered type matching err, ErrInternalServerStr and false if none
+func getMatchingErrorTypeInfo(err error) (ErrorTypeInfo, bool) {
+	registry.mu.RLock()
+	defer registry.mu.RUnlock()
+	for _, errType := range registry.types {
+		info := registry.infos[errType]
+		if info.matches(err) {
+			return info, true
+		}
+	}
+
+	return registry.infos[ErrInternalServerStr], false
+}
+
+func (info ErrorTypeInfo) matches(err error) bool {
+	return (info.Sentinel != nil && Is(err, info.Sentinel)) || (info.Match != nil && info.Match(err))
+}
+
+func isFn(targets ...error) func(err error) bool {
+	return func(err error) bool {
+		for _, target := range targets {
+			if Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
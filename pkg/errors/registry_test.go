@@ -0,0 +1,74 @@
+package errors
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorTypeRegistry(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	for errType, status := range map[ErrorType]int{
+		ErrRecordNotFoundStr:    http.StatusNotFound,
+		ErrAlreadyExistsStr:     http.StatusConflict,
+		ErrInProgressStr:        http.StatusConflict,
+		ErrTimedOutStr:          http.StatusGatewayTimeout,
+		ErrRequestCanceledStr:   StatusClientClosedRequest,
+		ErrResourceExhaustedStr: http.StatusTooManyRequests,
+	} {
+		a.Equal(status, GetHttpCodeFromErrorType(errType), errType)
+		// code sent by the server maps back to the same type on the client
+		a.Equal(errType, GetErrorTypeFromErrorCode(GetErrorCodeForErrorType(errType)), errType)
+	}
+	a.Equal(ErrAlreadyExistsStr, GetErrorTypeFromHttpStatus(http.StatusConflict))
+	a.Equal(ErrBadRequestStr, GetErrorTypeFromHttpStatus(http.StatusTeapot))
+
+	a.Equal(ErrRecordNotFoundStr, ToErrorResponse(fmt.Errorf("user: %w", ErrRecordNotFound)).ErrorType)
+	a.Equal(ErrTimedOutStr, ToErrorResponse(context.DeadlineExceeded).ErrorType)
+	a.Equal(ErrInternalServerStr, ToErrorResponse(New("boom")).ErrorType)
+	a.Equal(ErrInProgressStr, ToErrorResponse(&ErrorResponse{Code: errInProgress}).ErrorType)
+	a.Equal(ErrInternalServerStr, ToErrorResponse(ErrorResponse{Message: "boom"}).ErrorType)
+}
+
+// TestRegisterErrorType is not parallel as it registers a type in the process-wide registry,
+// which is unregistered before the parallel tests resume
+func TestRegisterErrorType(t *testing.T) {
+	a := require.New(t)
+
+	errPaymentDeclined := New("payment declined")
+	paymentDeclined := ErrorTypeInfo{
+		ErrorType:  "payment declined",
+		Code:       CustomErrorCodeStart,
+		HttpStatus: http.StatusPaymentRequired,
+		Message:    "Payment Declined",
+		Match: func(err error) bool {
+			return Is(err, errPaymentDeclined)
+		},
+	}
+	a.NoError(RegisterErrorType(paymentDeclined))
+	t.Cleanup(func() { unregisterErrorType(paymentDeclined.ErrorType) })
+	a.ErrorIs(RegisterErrorType(paymentDeclined), ErrAlreadyExists)
+	a.ErrorIs(RegisterErrorType(ErrorTypeInfo{ErrorType: "x", Code: 1, HttpStatus: http.StatusBadRequest}), ErrInvalidArgument)
+
+	errResp := ToErrorResponse(Wrap(errPaymentDeclined, "order %d", 1))
+	a.Equal(paymentDeclined.ErrorType, errResp.ErrorType)
+	a.Equal(CustomErrorCodeStart, errResp.Code)
+	a.Equal(http.StatusPaymentRequired, GetHttpCodeFromErrorType(errResp.ErrorType))
+	a.Equal(paymentDeclined.ErrorType, GetErrorTypeFromHttpStatus(http.StatusPaymentRequired))
+}
+
+func unregisterErrorType(errType ErrorType) {
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	delete(registry.codes, registry.infos[errType].Code)
+	delete(registry.infos, errType)
+	registry.types = slices.DeleteFunc(registry.types, func(t ErrorType) bool { return t == errType })
+	registry.indexHttpStatuses()
+}
@@ -0,0 +1,80 @@
+package errors
+
+import (
+	temporalsdk "go.temporal.io/sdk/temporal"
+)
+
+// ToApplicationError converts the error returned by an activity into a Temporal ApplicationError whose type is
+// the ErrorType of the error, so that the workflow can tell the failures apart using IsErrorOfType or
+// FromTemporalError. ApplicationError in the chain is returned as is.
+//   - *Error is converted by Error.ApplicationError
+//   - ErrorResponse keeps its type, message, details and retryability
+//   - other errors get the first registered type matching them e.g. ErrInvalidArgumentStr for ErrInvalidArgument,
+//     and its retryability. Errors matching no type get ErrInternalServerStr and stay retryable, so that
+//     the retry policy of Temporal applies to the unforeseen failures e.g. network blips.
+func ToApplicationError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	appErr := &temporalsdk.ApplicationError{}
+	if As(err, &appErr) {
+		return err
+	}
+
+	e := &Error{}
+	if As(err, &e) {
+		return e.ApplicationError()
+	}
+
+	errResp := ErrorResponse{}
+	errRespPtr := &ErrorResponse{}
+	switch {
+	case As(err, &errResp):
+	case As(err, &errRespPtr):
+		errResp = *errRespPtr
+	default:
+		return E(err).(*Error).ApplicationError()
+	}
+
+	var details []any
+	if len(errResp.ErrorDetails) > 0 {
+		details = []any{errResp.ErrorDetails}
+	}
+
+	return temporalsdk.NewApplicationErrorWithOptions(errResp.Message, string(errResp.ErrorType), temporalsdk.ApplicationErrorOptions{
+		NonRetryable: !errResp.Retryable,
+		Cause:        err,
+		Details:      details,
+	})
+}
+
+// FromTemporalError converts the failure of an activity or a child workflow back into an *Error at the workflow side,
+// so that errors.Is keeps working with the sentinels e.g. errors.Is(err, ErrRecordNotFound) for an ApplicationError
+// of type ErrRecordNotFoundStr, and errors.Is(err, ErrTransient) for a retryable one.
+// Temporal timeouts are converted to ErrTimedOutStr and cancellations to ErrRequestCanceledStr.
+// The original error is kept as the cause, other errors are returned as is.
+func FromTemporalError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	appErr := &temporalsdk.ApplicationError{}
+	timeoutErr := &temporalsdk.TimeoutError{}
+	canceledErr := &temporalsdk.CanceledError{}
+	switch {
+	case As(err, &appErr):
+		args := []any{ErrorType(appErr.Type()), appErr.Message(), err, Retryable(!appErr.NonRetryable())}
+		var details map[string]string
+		if appErr.HasDetails() && appErr.Details(&details) == nil {
+			args = append(args, Details(details))
+		}
+		return E(args...)
+	case As(err, &timeoutErr):
+		return E(ErrTimedOutStr, err)
+	case As(err, &canceledErr):
+		return E(ErrRequestCanceledStr, err)
+	default:
+		return err
+	}
+}
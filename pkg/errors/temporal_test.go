@@ -0,0 +1,53 @@
+package errors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	enumspb "go.temporal.io/api/enums/v1"
+	temporalsdk "go.temporal.io/sdk/temporal"
+)
+
+func TestTemporalErrorConversion(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	for _, tc := range []struct {
+		err       error
+		errType   ErrorType
+		retryable bool
+	}{
+		{err: Wrap(ErrInvalidArgument, "empty name"), errType: ErrInvalidArgumentStr},
+		{err: Wrap(ErrResourceExhausted, "quota"), errType: ErrResourceExhaustedStr, retryable: true},
+		{err: Wrap(ErrTransient, "db down"), errType: ErrTransientStr, retryable: true},
+		{err: Wrap(ErrPermanent, "bad data"), errType: ErrPermanentStr},
+		{err: New("connection reset by peer"), errType: ErrInternalServerStr, retryable: true},
+		{err: E(ErrInternalServerStr, "unexpected state"), errType: ErrInternalServerStr},
+		{err: E(ErrRecordNotFoundStr, "user not found", Details{"id": "u1"}), errType: ErrRecordNotFoundStr},
+		{err: NewErrorResponseWithDebug("In Progress", "", ErrInProgressStr), errType: ErrInProgressStr},
+	} {
+		actErr := ToApplicationError(tc.err)
+		appErr := &temporalsdk.ApplicationError{}
+		a.ErrorAs(actErr, &appErr, tc.errType)
+		a.Equal(string(tc.errType), appErr.Type())
+		a.Equal(!tc.retryable, appErr.NonRetryable(), tc.errType)
+		a.True(IsErrorOfType(actErr, tc.errType))
+
+		// sentinels of the activity error are matched at the workflow side
+		wfErr := FromTemporalError(actErr)
+		if info, _ := GetErrorTypeInfo(tc.errType); info.Sentinel != nil {
+			a.ErrorIs(wfErr, info.Sentinel)
+		}
+		a.Equal(tc.retryable, Is(wfErr, ErrTransient), tc.errType)
+		a.Equal(!tc.retryable, Is(wfErr, ErrPermanent), tc.errType)
+	}
+
+	e := &Error{}
+	a.ErrorAs(FromTemporalError(ToApplicationError(E(ErrRecordNotFoundStr, "user not found", Details{"id": "u1"}))), &e)
+	a.Equal(Details{"id": "u1"}, e.Details)
+	a.Equal("user not found", e.Message)
+
+	a.ErrorIs(FromTemporalError(temporalsdk.NewTimeoutError(enumspb.TIMEOUT_TYPE_START_TO_CLOSE, nil)), ErrTimedOut)
+	a.ErrorIs(FromTemporalError(temporalsdk.NewCanceledError()), ErrRequestCanceled)
+	a.Nil(ToApplicationError(nil))
+}
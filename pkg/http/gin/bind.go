@@ -0,0 +1,121 @@
+package ginhttp
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/validatorfx"
+)
+
+const (
+	bodySource = "body"
+)
+
+// BindRequest binds the request from all the sources of the HTTP request, in order:
+//   - body, decoded as per the Content-Type. Empty body binds the `form` tags from the query instead.
+//   - path params of the route, fields tagged with `path:"id"` for /users/:id
+//   - query params, fields tagged with `query:"expand"`
+//   - headers, fields tagged with `header:"X-Tenant"`
+//
+// The tags are the same as BindRequestParams so that a request type can be shared by the server and the client.
+// Values bound from path, query and headers take precedence over the body. `binding` tags are validated by gin
+// along with the body, use `validate` tags for the fields bound from the other sources.
+// Errors of every source are reported separately in the ErrorDetails of the errors.ErrInvalidArgumentStr response,
+// keyed by the source. Field errors of the body are keyed by the field, by its json path and translated like
+// the errors of `validate` tags if NewRequestValidationMiddleware runs before.
+func BindRequest(c *gin.Context, request any) error {
+	details := map[string]string{}
+	var debugMsgs []string
+	trans := getRequestBindingTranslator(c)
+	addErr := func(source string, err error) {
+		debugMsgs = append(debugMsgs, fmt.Sprintf("%s: %s", source, err.Error()))
+		if fieldErrs, ok := validatorfx.GetFieldErrors(err, trans); ok {
+			for field, reason := range fieldErrs {
+				details[field] = reason
+			}
+			return
+		}
+		details[source] = err.Error()
+	}
+
+	if err := bindBody(c, request); err != nil {
+		addErr(bodySource, err)
+	}
+
+	rt := reflect.TypeOf(request)
+	for rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt.Kind() == reflect.Struct {
+		params := make(map[string][]string, len(c.Params))
+		for _, p := range c.Params {
+			params[p.Key] = append(params[p.Key], p.Value)
+		}
+		query := c.Request.URL.Query()
+
+		sources := []struct {
+			tag    string
+			lookup func(name string) []string
+		}{
+			{tag: pathTag, lookup: func(name string) []string { return params[name] }},
+			{tag: queryTag, lookup: func(name string) []string { return query[name] }},
+			{tag: headerTag, lookup: c.Request.Header.Values},
+		}
+		for _, src := range sources {
+			if err := binding.MapFormWithTag(request, getTaggedValues(rt, src.tag, src.lookup), src.tag); err != nil {
+				addErr(src.tag, err)
+			}
+		}
+	}
+
+	if len(debugMsgs) == 0 {
+		return nil
+	}
+
+	errResp := errors.NewErrorResponseWithDebug("Invalid Argument", strings.Join(debugMsgs, "; "), errors.ErrInvalidArgumentStr)
+	errResp.ErrorDetails = details
+	return errResp
+}
+
+func bindBody(c *gin.Context, request any) error {
+	if c.Request.Body == nil || c.Request.Body == http.NoBody || c.Request.ContentLength == 0 {
+		return c.ShouldBindWith(request, binding.Query)
+	}
+
+	return c.ShouldBind(request)
+}
+
+// getTaggedValues looks up the values of the names used by the fields tagged with tag.
+// Only the tagged names are looked up, so that the untagged fields are not bound by their field names.
+func getTaggedValues(rt reflect.Type, tag string, lookup func(name string) []string) map[string][]string {
+	values := map[string][]string{}
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			for name, vals := range getTaggedValues(field.Type, tag, lookup) {
+				values[name] = vals
+			}
+			continue
+		}
+
+		t, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		name, _ := parseParamTag(t)
+		if name == "" || name == "-" {
+			continue
+		}
+		if vals := lookup(name); len(vals) > 0 {
+			values[name] = vals
+		}
+	}
+
+	return values
+}
@@ -0,0 +1,105 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type updateUserRequest struct {
+	ID     int64    `json:"-" path:"id"`
+	Expand []string `json:"-" query:"expand"`
+	Tenant string   `json:"-" header:"X-Tenant"`
+	Name   string   `json:"name"`
+}
+
+func TestNewGinEndpoint_MultiSourceBinding(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	var bound *updateUserRequest
+	ep := func(ctx context.Context, r *updateUserRequest) (*greetResponse, error) {
+		bound = r
+		return &greetResponse{}, nil
+	}
+
+	router := gin.New()
+	router.PATCH("/users/:id", NewGinEndpoint(ep))
+
+	serve := func(path, body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPatch, path, strings.NewReader(body))
+		if body != "" {
+			req.Header.Set("Content-Type", ContentTypeJSON)
+		}
+		req.Header.Set("x-tenant", "acme")
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	a := require.New(t)
+	w := serve("/users/42?expand=roles&expand=groups", `{"name":"foo"}`)
+	a.Equal(http.StatusOK, w.Code)
+	a.Equal(&updateUserRequest{ID: 42, Expand: []string{"roles", "groups"}, Tenant: "acme", Name: "foo"}, bound)
+
+	// empty body
+	w = serve("/users/42", "")
+	a.Equal(http.StatusOK, w.Code)
+	a.Equal(&updateUserRequest{ID: 42, Tenant: "acme"}, bound)
+
+	w = serve("/users/abc", `{"name":`)
+	a.Equal(http.StatusBadRequest, w.Code)
+	errResp := errors.ErrorResponse{}
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &errResp))
+	a.Equal(errors.ErrInvalidArgumentStr, errResp.ErrorType)
+	a.Contains(errResp.ErrorDetails, "body")
+	a.Contains(errResp.ErrorDetails, "path")
+	a.NotContains(errResp.ErrorDetails, "query")
+}
+
+type createAccountRequest struct {
+	Email   string `json:"email" binding:"required,email"`
+	Address struct {
+		City string `json:"city" binding:"required"`
+	} `json:"address"`
+	Name string `json:"name" validate:"required"`
+}
+
+// not parallel as the validation middleware configures the validator of gin binding
+func TestBindRequest_FieldErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(newTestRequestValidationMiddleware())
+	router.POST("/accounts", NewGinEndpoint(func(ctx context.Context, r *createAccountRequest) (*greetResponse, error) {
+		return &greetResponse{}, nil
+	}))
+
+	serve := func(body string) errors.ErrorResponse {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/accounts", strings.NewReader(body))
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		router.ServeHTTP(w, req)
+
+		errResp := errors.ErrorResponse{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errResp))
+		return errResp
+	}
+
+	a := require.New(t)
+	// `binding` tags are validated by gin along with the body
+	a.Equal(map[string]string{
+		"email":        "email must be a valid email address",
+		"address.city": "city is a required field",
+	}, serve(`{"email":"foo"}`).ErrorDetails)
+
+	// `validate` tags are validated once bound, keyed and translated alike
+	a.Equal(map[string]string{
+		"name": "name is a required field",
+	}, serve(`{"email":"foo@example.com","address":{"city":"Pune"}}`).ErrorDetails)
+}
@@ -1,15 +1,18 @@
 package ginhttp
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
 	"github.com/nitesh237/go-server-template/pkg/errors"
+	httppkg "github.com/nitesh237/go-server-template/pkg/http"
 )
 
 // EncodeRequestFunc encodes the passed request object into the HTTP request
@@ -30,137 +33,240 @@ type CreateRequestFunc[req any] func(context.Context, *req) (*http.Request, erro
 // JSON decodes from the response body to the concrete response type.
 type DecodeResponseFunc[resp any] func(context.Context, *http.Response) (response *resp, err error)
 
-// genericHttpRequestEncoder is a transport/http.EncodeRequestFunc that
-// SON-encodes any request to the request body. Primarily useful in a client.
-func genericHttpRequestEncoder[req any](_ context.Context, r *http.Request, request *req) error {
-	b, err := json.Marshal(request)
+// ErrorDecoder decodes errors.ErrorResponse from the response body.
+// If the body is not an errors.ErrorResponse e.g. html or plain text error page of a third party,
+// the error type is derived from the status code and the body is set as the debug message.
+// Error type of the body is kept as is, falling back to the one registered with the code, or the status code without it.
+// application/problem+json bodies are decoded as errors.ProblemDetails.
+func ErrorDecoder(r *http.Response) error {
+	b, err := io.ReadAll(r.Body)
 	if err != nil {
 		return err
 	}
 
-	r.Header.Set("Content-Type", "application/json")
-	r.Body = io.NopCloser(bytes.NewBuffer(b))
-	return nil
-}
-
-// genericHttpResponseDecoder is a transport/http.DecodeResponseFunc that decodes
-// a JSON-encoded concat response from the HTTP response body. If the response
-// as a non-200 status code, we will interpret that as an error and attempt to
-//
-//	decode the specific error message from the response body.
-func genericHttpResponseDecoder[resp any](_ context.Context, r *http.Response) (*resp, error) {
-	if r.StatusCode != http.StatusOK {
-		return nil, ErrorDecoder(r)
-	}
-
-	res := new(resp)
-	err := json.NewDecoder(r.Body).Decode(&res)
-	if err != nil {
-		return nil, err
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType == errors.ContentTypeProblemJSON {
+		var problem errors.ProblemDetails
+		if err = json.Unmarshal(b, &problem); err == nil {
+			if problem.Status == 0 {
+				problem.Status = r.StatusCode
+			}
+			errResp := problem.ToErrorResponse()
+			return &errResp
+		}
 	}
 
-	return res, nil
-}
-
-func ErrorDecoder(r *http.Response) error {
-	dc := json.NewDecoder(r.Body)
 	var errResp errors.ErrorResponse
-	if err := dc.Decode(&errResp); err != nil {
-		return err
+	if err = json.Unmarshal(b, &errResp); err != nil || errResp.Message == "" {
+		errType := errors.GetErrorTypeFromHttpStatus(r.StatusCode)
+		return &errors.ErrorResponse{
+			Message:      http.StatusText(r.StatusCode),
+			DebugMessage: string(b),
+			Code:         errors.GetErrorCodeForErrorType(errType),
+			ErrorType:    errType,
+		}
 	}
 
-	errResp.ErrorType = errors.GetErrorTypeFromErrorCode(errResp.Code)
+	switch {
+	case errResp.ErrorType != "":
+	case errResp.Code == 0:
+		// zero code is not set, rather than the code of the first registered type
+		errResp.ErrorType = errors.GetErrorTypeFromHttpStatus(r.StatusCode)
+		errResp.Code = errors.GetErrorCodeForErrorType(errResp.ErrorType)
+	default:
+		errResp.ErrorType = errors.GetErrorTypeFromErrorCode(errResp.Code)
+	}
 	return &errResp
 }
 
+// RequestDecorator modifies the outgoing HTTP request based on the passed request object,
+// e.g. to set headers or the path params which can't be expressed through BindRequestParams.
+type RequestDecorator[req any] func(httpReq *http.Request, r *req) (*http.Request, error)
+
+// Client is a generic client for a single remote method. It's configured through ClientOption, and
+// its Endpoint is wrapped by the middlewares in the order they are passed i.e. first middleware is the outermost.
 type Client[req, resp any] struct {
-	client *http.Client
-	req    CreateRequestFunc[req]
-	dec    DecodeResponseFunc[resp]
+	client       *http.Client
+	method       string
+	target       *url.URL
+	create       CreateRequestFunc[req]
+	enc          EncodeRequestFunc[req]
+	dec          DecodeResponseFunc[resp]
+	successCodes []int
+	decorators   []RequestDecorator[req]
+	tokenSource  TokenSource
+	timeout      time.Duration
+	limiter      httppkg.Limiter
+	middlewares  []Middleware[req, resp]
 }
 
-// NewClient constructs a usable Client for a single remote method.
-func NewClient[req, resp any](client *http.Client, method string, tgt *url.URL) *Client[req, resp] {
-	return &Client[req, resp]{
+// NewClientWithOptions constructs a usable Client for a single remote method.
+// By default, the request is JSON-encoded and the response is JSON-decoded, any 2xx status code is accepted as success.
+// The path of tgt can be a template e.g. /users/{id}, the request fields tagged with `path`, `query` and `header`
+// are bound to the HTTP request as per BindRequestParams. GET, HEAD and DELETE requests are sent without a body.
+func NewClientWithOptions[req, resp any](client *http.Client, method string, tgt *url.URL, opts ...ClientOption[req, resp]) *Client[req, resp] {
+	c := &Client[req, resp]{
 		client: client,
-		req:    defaultCreateRequestFunc(method, tgt, genericHttpRequestEncoder[req]),
-		dec:    genericHttpResponseDecoder[resp],
+		method: method,
+		target: tgt,
+		enc:    JSONRequestEncoder[req],
+		dec:    JSONResponseDecoder[resp],
 	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
 }
 
 // NewClient constructs a usable Client for a single remote method.
+// The path of tgt can be a template e.g. /users/{id}, the request fields tagged with `path`, `query` and `header`
+// are bound to the HTTP request as per BindRequestParams. GET, HEAD and DELETE requests are sent without a body.
+func NewClient[req, resp any](client *http.Client, method string, tgt *url.URL) *Client[req, resp] {
+	return NewClientWithOptions[req, resp](client, method, tgt)
+}
+
+// NewClientWithDecorator constructs a usable Client for a single remote method
+// which decorates the HTTP request with httpReqDecorator in order.
 func NewClientWithDecorator[req, resp any](client *http.Client, method string, tgt *url.URL, httpReqDecorator ...func(httpReq *http.Request, r *req) (*http.Request, error)) *Client[req, resp] {
-	return &Client[req, resp]{
-		client: client,
-		req:    defaultCreateRequestFunc(method, tgt, genericHttpRequestEncoder[req], httpReqDecorator...),
-		dec:    genericHttpResponseDecoder[resp],
+	decorators := make([]RequestDecorator[req], 0, len(httpReqDecorator))
+	for _, d := range httpReqDecorator {
+		decorators = append(decorators, d)
 	}
+
+	return NewClientWithOptions(client, method, tgt, WithDecorators[req, resp](decorators...))
 }
 
-// NewClient constructs a usable Client for a single remote method.
+// NewLimitedClient constructs a usable Client for a single remote method
+// which rate limits and bulkheads every call made through the endpoint as per limits.
+func NewLimitedClient[req, resp any](client *http.Client, method string, tgt *url.URL, limits *cfg.Limits) *Client[req, resp] {
+	return NewClientWithOptions(client, method, tgt, WithLimits[req, resp](limits))
+}
+
+// NewRetryableClientNative constructs a usable Client for a single remote method which retries as per the client.
 func NewRetryableClientNative[req, resp any](client *retryablehttp.Client, method string, tgt *url.URL) *Client[req, resp] {
-	return &Client[req, resp]{
-		client: client.StandardClient(),
-		req:    defaultCreateRequestFunc(method, tgt, genericHttpRequestEncoder[req]),
-		dec:    genericHttpResponseDecoder[resp],
-	}
+	return NewClientWithOptions(nil, method, tgt, WithRetryableClient[req, resp](client))
 }
 
-func defaultCreateRequestFunc[req any](method string, target *url.URL, enc EncodeRequestFunc[req], httpReqDecorator ...func(httpReq *http.Request, r *req) (*http.Request, error)) CreateRequestFunc[req] {
-	return func(ctx context.Context, request *req) (*http.Request, error) {
+// NewClientWithCodec constructs a usable Client for a single remote method which encodes the request with enc
+// and decodes the response with dec, e.g. FormRequestEncoder, MultipartRequestEncoder, ProtobufResponseDecoder,
+// StreamResponseDecoder etc. Responses with status code other than successCodes are decoded as errors,
+// if successCodes is empty any 2xx status code is accepted.
+func NewClientWithCodec[req, resp any](client *http.Client, method string, tgt *url.URL, enc EncodeRequestFunc[req], dec DecodeResponseFunc[resp], successCodes ...int) *Client[req, resp] {
+	return NewClientWithOptions(client, method, tgt,
+		WithCodec(enc, dec),
+		WithSuccessCodes[req, resp](successCodes...),
+	)
+}
 
-		req, err := http.NewRequest(method, target.String(), nil)
+// createRequest creates the outgoing HTTP request by binding the request params, setting the auth token,
+// applying the decorators and encoding the body in that order.
+// If the request is created through WithCreateRequest, binding and encoding are left to it.
+func (c *Client[req, resp]) createRequest(ctx context.Context, request *req) (*http.Request, error) {
+	if c.create != nil {
+		httpReq, err := c.create(ctx, request)
 		if err != nil {
 			return nil, err
 		}
+		return c.authorizeAndDecorate(ctx, httpReq.WithContext(ctx), request)
+	}
 
-		if len(httpReqDecorator) > 0 {
-			for _, httpDec := range httpReqDecorator {
-				req, err = httpDec(req, request)
-				if err != nil {
-					return nil, errors.Wrap(err, "unable to decorate http request")
-				}
-			}
-		}
+	httpReq, err := http.NewRequestWithContext(ctx, c.method, c.target.String(), nil)
+	if err != nil {
+		return nil, err
+	}
 
-		if err = enc(ctx, req, request); err != nil {
-			return nil, err
+	if err = BindRequestParams(httpReq, request); err != nil {
+		return nil, errors.Wrap(err, "unable to bind request params")
+	}
+
+	if httpReq, err = c.authorizeAndDecorate(ctx, httpReq, request); err != nil {
+		return nil, err
+	}
+
+	if hasNoBody(c.method) {
+		return httpReq, nil
+	}
+
+	if err = c.enc(ctx, httpReq, request); err != nil {
+		return nil, err
+	}
+
+	return httpReq, nil
+}
+
+// authorizeAndDecorate sets the auth token and applies the decorators to the outgoing HTTP request
+func (c *Client[req, resp]) authorizeAndDecorate(ctx context.Context, httpReq *http.Request, request *req) (*http.Request, error) {
+	var err error
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to get auth token")
 		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
 
-		return req, nil
+	for _, httpDec := range c.decorators {
+		httpReq, err = httpDec(httpReq, request)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decorate http request")
+		}
 	}
+
+	return httpReq, nil
 }
 
 // Endpoint returns a usable Go kit endpoint that calls the remote HTTP endpoint.
-func (c Client[req, resp]) Endpoint() Endpoint[req, resp] {
-	return func(ctx context.Context, r *req) (*resp, error) {
-		ctx, cancel := context.WithCancel(ctx)
+func (c *Client[req, resp]) Endpoint() Endpoint[req, resp] {
+	ep := func(ctx context.Context, r *req) (*resp, error) {
+		if c.limiter != nil {
+			release, err := c.limiter.Acquire(ctx)
+			if err != nil {
+				return nil, err
+			}
+			defer release()
+		}
 
-		var (
-			resp *http.Response
-			err  error
-		)
+		var cancel context.CancelFunc
+		if c.timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		} else {
+			ctx, cancel = context.WithCancel(ctx)
+		}
 
-		req, err := c.req(ctx, r)
+		httpReq, err := c.createRequest(ctx, r)
 		if err != nil {
 			cancel()
 			return nil, err
 		}
 
-		resp, err = c.client.Do(req.WithContext(ctx))
+		httpResp, err := c.client.Do(httpReq)
 		if err != nil {
 			cancel()
 			return nil, err
 		}
 
-		defer resp.Body.Close()
-		defer cancel()
+		return decodeResponse(ctx, httpResp, cancel, SuccessStatusDecoder(c.dec, c.successCodes...))
+	}
 
-		response, err := c.dec(ctx, resp)
-		if err != nil {
-			return nil, err
-		}
+	return Chain(c.middlewares...)(ep)
+}
+
+// decodeResponse decodes the response and closes the body unless the decoded response is an io.Closer
+// e.g. StreamBody, in which case the caller owns the body. The context is canceled once the body is closed.
+func decodeResponse[resp any](ctx context.Context, r *http.Response, cancel context.CancelFunc, dec DecodeResponseFunc[resp]) (*resp, error) {
+	r.Body = &cancelOnCloseBody{ReadCloser: r.Body, cancel: cancel}
+
+	response, err := dec(ctx, r)
+	if err != nil {
+		r.Body.Close()
+		return nil, err
+	}
 
-		return response, nil
+	if _, ok := any(response).(io.Closer); !ok {
+		r.Body.Close()
 	}
+
+	return response, nil
 }
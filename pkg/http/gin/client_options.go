@@ -0,0 +1,92 @@
+package ginhttp
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	httppkg "github.com/nitesh237/go-server-template/pkg/http"
+)
+
+// ClientOption configures a Client
+type ClientOption[req, resp any] func(*Client[req, resp])
+
+// TokenSource returns the bearer token to be set in the Authorization header of the outgoing request
+type TokenSource func(ctx context.Context) (string, error)
+
+// StaticTokenSource returns a TokenSource which always returns the token
+func StaticTokenSource(token string) TokenSource {
+	return func(context.Context) (string, error) {
+		return token, nil
+	}
+}
+
+// WithRetryableClient sends the requests through the retryable client which retries as per its policy.
+// The request body is buffered by the retryable client so that it can be replayed on retries.
+func WithRetryableClient[req, resp any](client *retryablehttp.Client) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.client = client.StandardClient()
+	}
+}
+
+// WithDecorators decorates the outgoing HTTP request with the decorators in order
+func WithDecorators[req, resp any](decorators ...RequestDecorator[req]) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.decorators = append(c.decorators, decorators...)
+	}
+}
+
+// WithCreateRequest creates the outgoing HTTP request with create instead of binding the request params
+// and encoding the body. The auth token and the decorators are still applied to the created request.
+func WithCreateRequest[req, resp any](create CreateRequestFunc[req]) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.create = create
+	}
+}
+
+// WithCodec encodes the request with enc and decodes the successful response with dec
+func WithCodec[req, resp any](enc EncodeRequestFunc[req], dec DecodeResponseFunc[resp]) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.enc = enc
+		c.dec = dec
+	}
+}
+
+// WithSuccessCodes accepts only the codes as successful responses, rest are decoded as errors.
+// By default, any 2xx status code is accepted.
+func WithSuccessCodes[req, resp any](codes ...int) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.successCodes = codes
+	}
+}
+
+// WithAuthToken sets the token from the source as the bearer token of the outgoing request
+func WithAuthToken[req, resp any](source TokenSource) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.tokenSource = source
+	}
+}
+
+// WithTimeout limits the time of a single call made through the endpoint, including retries
+// and reading the response body
+func WithTimeout[req, resp any](timeout time.Duration) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.timeout = timeout
+	}
+}
+
+// WithLimits rate limits and bulkheads every call made through the endpoint as per limits.
+// Limits are acquired once per call and are held across the retries.
+func WithLimits[req, resp any](limits *cfg.Limits) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.limiter = httppkg.NewLimiter(limits)
+	}
+}
+
+// WithMiddlewares wraps the endpoint with the middlewares, first middleware being the outermost
+func WithMiddlewares[req, resp any](middlewares ...Middleware[req, resp]) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.middlewares = append(c.middlewares, middlewares...)
+	}
+}
@@ -0,0 +1,135 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type retryEchoResponse struct {
+	ContentType string          `json:"content_type"`
+	Auth        string          `json:"auth"`
+	Body        json.RawMessage `json:"body"`
+}
+
+func TestRetryableClient_ReplaysBody(t *testing.T) {
+	t.Parallel()
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"content_type":"` + r.Header.Get("Content-Type") + `","auth":"` + r.Header.Get("Authorization") + `","body":` + string(b) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	tgt, _ := url.Parse(srv.URL)
+
+	retryClient := retryablehttp.NewClient()
+	retryClient.Logger = nil
+	retryClient.RetryWaitMin = time.Millisecond
+	retryClient.RetryWaitMax = time.Millisecond
+
+	var order []string
+	mw := func(name string) Middleware[createUserRequest, retryEchoResponse] {
+		return func(next Endpoint[createUserRequest, retryEchoResponse]) Endpoint[createUserRequest, retryEchoResponse] {
+			return func(ctx context.Context, r *createUserRequest) (*retryEchoResponse, error) {
+				order = append(order, name)
+				return next(ctx, r)
+			}
+		}
+	}
+
+	res, err := NewRetryableClient(retryClient, http.MethodPost, tgt,
+		WithAuthToken[createUserRequest, retryEchoResponse](StaticTokenSource("secret")),
+		WithTimeout[createUserRequest, retryEchoResponse](time.Second),
+		WithMiddlewares(mw("outer"), mw("inner")),
+	).Endpoint()(context.Background(), &createUserRequest{Name: "foo"})
+
+	a := require.New(t)
+	a.NoError(err)
+	a.Equal(int32(2), attempts.Load(), "request not retried")
+	a.Equal(ContentTypeJSON, res.ContentType, "content type not set")
+	a.Equal("Bearer secret", res.Auth, "auth token not set")
+	a.JSONEq(`{"name":"foo","tags":null}`, string(res.Body), "body not replayed on retry")
+	a.Equal([]string{"outer", "inner"}, order, "middlewares not applied in order")
+}
+
+func TestRetryableClient_DeprecatedRequestFuncs(t *testing.T) {
+	t.Parallel()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		_, _ = w.Write([]byte(`{"content_type":"` + r.Header.Get("Content-Type") + `","auth":"` + r.Header.Get("Authorization") + `","body":` + string(b) + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	tgt, _ := url.Parse(srv.URL)
+
+	retryClient := retryablehttp.NewClient()
+	retryClient.Logger = nil
+
+	enc := EncodeRetryableRequestFunc[createUserRequest](func(_ context.Context, r *retryablehttp.Request, request *createUserRequest) error {
+		r.Header.Set("Content-Type", "application/vnd.user+json")
+		return r.SetBody([]byte(`{"name":"` + request.Name + `"}`))
+	})
+	create := CreateRetryableRequestFunc[createUserRequest](func(ctx context.Context, request *createUserRequest) (*retryablehttp.Request, error) {
+		r, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPut, tgt.String(), []byte(`{"name":"`+request.Name+`"}`))
+		if err != nil {
+			return nil, err
+		}
+		r.Header.Set("Content-Type", "text/plain")
+		return r, nil
+	})
+
+	a := require.New(t)
+	res, err := NewRetryableClient(retryClient, http.MethodPost, tgt,
+		WithRetryableRequestEncoder[createUserRequest, retryEchoResponse](enc),
+	).Endpoint()(context.Background(), &createUserRequest{Name: "foo"})
+	a.NoError(err)
+	a.Equal("application/vnd.user+json", res.ContentType, "encoder not applied")
+	a.JSONEq(`{"name":"foo"}`, string(res.Body), "body not encoded")
+
+	res, err = NewRetryableClient(retryClient, http.MethodPost, tgt,
+		WithCreateRetryableRequest[createUserRequest, retryEchoResponse](create),
+		WithAuthToken[createUserRequest, retryEchoResponse](StaticTokenSource("secret")),
+	).Endpoint()(context.Background(), &createUserRequest{Name: "bar"})
+	a.NoError(err)
+	a.Equal("text/plain", res.ContentType, "created request not sent")
+	a.Equal("Bearer secret", res.Auth, "auth token not set")
+	a.JSONEq(`{"name":"bar"}`, string(res.Body), "body not sent")
+}
+
+func TestErrorDecoder(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	decode := func(status int, body string) *errors.ErrorResponse {
+		err := ErrorDecoder(&http.Response{StatusCode: status, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(body))})
+		errResp := &errors.ErrorResponse{}
+		a.ErrorAs(err, &errResp)
+		return errResp
+	}
+
+	errResp := decode(http.StatusConflict, `{"message":"in progress","code":`+strconv.Itoa(errors.GetErrorCodeForErrorType(errors.ErrInProgressStr))+`}`)
+	a.Equal(errors.ErrInProgressStr, errResp.ErrorType)
+
+	// body without code and type is typed by the status, not by the zero code
+	errResp = decode(http.StatusServiceUnavailable, `{"message":"try again later"}`)
+	a.Equal(errors.ErrTransientStr, errResp.ErrorType)
+	a.Equal(errors.GetErrorCodeForErrorType(errors.ErrTransientStr), errResp.Code)
+
+	errResp = decode(http.StatusInternalServerError, `<html>oops</html>`)
+	a.Equal(errors.ErrInternalServerStr, errResp.ErrorType)
+}
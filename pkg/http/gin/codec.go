@@ -0,0 +1,386 @@
+package ginhttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"reflect"
+	"slices"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeForm     = "application/x-www-form-urlencoded"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeOctet    = "application/octet-stream"
+)
+
+// ResponseMeta can be embedded in the response type to get access to the status code, the headers
+// and the cookies of the HTTP response. On the server side, NewGinEndpoint answers with them instead of
+// 200 e.g. 201 with a Location header, 202 for async jobs or 204 with no content.
+type ResponseMeta struct {
+	StatusCode int            `json:"-"`
+	Header     http.Header    `json:"-"`
+	Cookies    []*http.Cookie `json:"-"`
+}
+
+// unforwardedHeaders are specific to the connection or the body of the HTTP response they were received with,
+// and cookies are carried by ResponseMeta.Cookies, so they are neither captured nor written from ResponseMeta.Header
+var unforwardedHeaders = map[string]struct{}{
+	"Connection":        {},
+	"Keep-Alive":        {},
+	"Proxy-Connection":  {},
+	"Te":                {},
+	"Trailer":           {},
+	"Transfer-Encoding": {},
+	"Upgrade":           {},
+	"Content-Length":    {},
+	"Content-Type":      {},
+	"Content-Encoding":  {},
+	"Date":              {},
+	"Set-Cookie":        {},
+}
+
+// SetResponseMeta captures the status code, the cookies and the headers of the response except unforwardedHeaders
+func (m *ResponseMeta) SetResponseMeta(statusCode int, header http.Header) {
+	m.StatusCode = statusCode
+	m.Cookies = (&http.Response{Header: header}).Cookies()
+	m.Header = make(http.Header, len(header))
+	for key, vals := range header {
+		if _, ok := unforwardedHeaders[http.CanonicalHeaderKey(key)]; !ok {
+			m.Header[key] = vals
+		}
+	}
+}
+
+func (m *ResponseMeta) GetResponseMeta() *ResponseMeta {
+	return m
+}
+
+// SetHeader sets the header of the response, replacing the existing values.
+// Cookies are set through SetCookie, the headers of the body e.g. Content-Type are set by the response itself.
+func (m *ResponseMeta) SetHeader(key, value string) {
+	if m.Header == nil {
+		m.Header = http.Header{}
+	}
+	m.Header.Set(key, value)
+}
+
+// SetCookie adds the cookie to the response
+func (m *ResponseMeta) SetCookie(cookie *http.Cookie) {
+	m.Cookies = append(m.Cookies, cookie)
+}
+
+type responseMetaSetter interface {
+	SetResponseMeta(statusCode int, header http.Header)
+}
+
+type responseMetaGetter interface {
+	GetResponseMeta() *ResponseMeta
+}
+
+// StreamBody streams raw bytes as the body of the request or the response.
+// When used as a response, Endpoint hands over the ownership of Body to the caller who must Close it.
+// NewGinEndpoint streams it as the body of the response and closes it once written.
+type StreamBody struct {
+	ResponseMeta
+	Body        io.Reader
+	ContentType string
+	// ContentLength of Body, 0 or -1 if unknown
+	ContentLength int64
+}
+
+func (s *StreamBody) Read(p []byte) (int, error) {
+	return s.Body.Read(p)
+}
+
+func (s *StreamBody) Close() error {
+	if c, ok := s.Body.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// MultipartFile is a file part of a multipart request.
+// Fields of type *MultipartFile or []*MultipartFile in the request are sent as files by MultipartRequestEncoder.
+type MultipartFile struct {
+	FileName    string
+	ContentType string
+	Content     io.Reader
+}
+
+var multipartFileType = reflect.TypeOf(&MultipartFile{})
+
+// SuccessStatusDecoder decodes the response with dec if the status code is one of the codes,
+// otherwise the error is decoded using ErrorDecoder. If codes is empty, any 2xx status code is accepted.
+// Response types embedding ResponseMeta get the status code and the headers of the response.
+func SuccessStatusDecoder[resp any](dec DecodeResponseFunc[resp], codes ...int) DecodeResponseFunc[resp] {
+	return func(ctx context.Context, r *http.Response) (*resp, error) {
+		if !IsSuccessStatus(r.StatusCode, codes...) {
+			return nil, ErrorDecoder(r)
+		}
+
+		res, err := dec(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if m, ok := any(res).(responseMetaSetter); ok {
+			m.SetResponseMeta(r.StatusCode, r.Header)
+		}
+
+		return res, nil
+	}
+}
+
+// IsSuccessStatus checks if the status code is one of the codes, or 2xx if codes is empty
+func IsSuccessStatus(statusCode int, codes ...int) bool {
+	if len(codes) == 0 {
+		return statusCode >= http.StatusOK && statusCode < http.StatusMultipleChoices
+	}
+
+	return slices.Contains(codes, statusCode)
+}
+
+// JSONRequestEncoder JSON-encodes the request to the request body
+// Fields bound by BindRequestParams are excluded from the body.
+func JSONRequestEncoder[req any](_ context.Context, r *http.Request, request *req) error {
+	b, err := marshalJSONBody(request)
+	if err != nil {
+		return err
+	}
+
+	setBufferedBody(r, b, ContentTypeJSON)
+	return nil
+}
+
+// JSONResponseDecoder JSON-decodes the response body. Empty body results in a zero value response.
+func JSONResponseDecoder[resp any](_ context.Context, r *http.Response) (*resp, error) {
+	res := new(resp)
+	if r.StatusCode == http.StatusNoContent || r.ContentLength == 0 {
+		return res, nil
+	}
+
+	err := json.NewDecoder(r.Body).Decode(res)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// FormRequestEncoder form-urlencodes the request to the request body.
+// The request can either be url.Values or a struct whose fields are named by `form` tags.
+func FormRequestEncoder[req any](_ context.Context, r *http.Request, request *req) error {
+	values, err := EncodeFormValues(request)
+	if err != nil {
+		return err
+	}
+
+	setBufferedBody(r, []byte(values.Encode()), ContentTypeForm)
+	return nil
+}
+
+// MultipartRequestEncoder streams the request as multipart/form-data.
+// Fields of type *MultipartFile or []*MultipartFile are sent as files and rest of the fields as values
+// named by `form` tags. Since the body is streamed, it can't be replayed on retries.
+func MultipartRequestEncoder[req any](_ context.Context, r *http.Request, request *req) error {
+	values, files, err := encodeMultipartFields(request)
+	if err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		pw.CloseWithError(writeMultipart(mw, values, files))
+	}()
+
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	r.Body = pr
+	return nil
+}
+
+// ProtobufRequestEncoder marshals the request to the request body. The request must be a proto.Message.
+func ProtobufRequestEncoder[req any](_ context.Context, r *http.Request, request *req) error {
+	m, ok := any(request).(proto.Message)
+	if !ok {
+		return errors.Wrap(errors.ErrInvalidArgument, "%T is not a proto message", request)
+	}
+
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	setBufferedBody(r, b, ContentTypeProtobuf)
+	return nil
+}
+
+// ProtobufResponseDecoder unmarshals the response body. The response must be a proto.Message.
+func ProtobufResponseDecoder[resp any](_ context.Context, r *http.Response) (*resp, error) {
+	res := new(resp)
+	m, ok := any(res).(proto.Message)
+	if !ok {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "%T is not a proto message", res)
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = proto.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// StreamRequestEncoder streams StreamBody.Body as the request body
+func StreamRequestEncoder(_ context.Context, r *http.Request, request *StreamBody) error {
+	contentType := request.ContentType
+	if contentType == "" {
+		contentType = ContentTypeOctet
+	}
+
+	r.Header.Set("Content-Type", contentType)
+	r.Body = io.NopCloser(request.Body)
+	if rc, ok := request.Body.(io.ReadCloser); ok {
+		r.Body = rc
+	}
+	r.ContentLength = request.ContentLength
+	return nil
+}
+
+// StreamResponseDecoder hands over the response body as is. The caller must Close the returned StreamBody.
+func StreamResponseDecoder(_ context.Context, r *http.Response) (*StreamBody, error) {
+	return &StreamBody{
+		Body:          r.Body,
+		ContentType:   r.Header.Get("Content-Type"),
+		ContentLength: r.ContentLength,
+	}, nil
+}
+
+// EncodeFormValues encodes v into url.Values. v can either be url.Values or a struct whose fields are named
+// by `form` tags, fields without the tag are named by the field name. Fields tagged with "-" and
+// the fields bound by BindRequestParams are skipped.
+func EncodeFormValues(v any) (url.Values, error) {
+	values, _, err := encodeMultipartFields(v)
+	return values, err
+}
+
+func encodeMultipartFields(v any) (url.Values, map[string][]*MultipartFile, error) {
+	switch vals := v.(type) {
+	case url.Values:
+		return vals, nil, nil
+	case *url.Values:
+		return *vals, nil, nil
+	}
+
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, nil, errors.Wrap(errors.ErrInvalidArgument, "unsupported form type %T", v)
+	}
+
+	values := url.Values{}
+	files := map[string][]*MultipartFile{}
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Tag.Get("form")
+		if name == "-" || isParamField(field) {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		fv := rv.Field(i)
+		switch {
+		case field.Type == multipartFileType:
+			if !fv.IsNil() {
+				files[name] = append(files[name], fv.Interface().(*MultipartFile))
+			}
+		case field.Type.Kind() == reflect.Slice && field.Type.Elem() == multipartFileType:
+			files[name] = append(files[name], fv.Interface().([]*MultipartFile)...)
+		default:
+			vals, err := formatParamValues(fv, false)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "failed to format form field %s", name)
+			}
+			for _, val := range vals {
+				values.Add(name, val)
+			}
+		}
+	}
+
+	return values, files, nil
+}
+
+func writeMultipart(mw *multipart.Writer, values url.Values, files map[string][]*MultipartFile) error {
+	for name, vals := range values {
+		for _, val := range vals {
+			if err := mw.WriteField(name, val); err != nil {
+				return err
+			}
+		}
+	}
+
+	for name, fs := range files {
+		for _, f := range fs {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, name, f.FileName))
+			contentType := f.ContentType
+			if contentType == "" {
+				contentType = ContentTypeOctet
+			}
+			h.Set("Content-Type", contentType)
+
+			part, err := mw.CreatePart(h)
+			if err != nil {
+				return err
+			}
+			if _, err = io.Copy(part, f.Content); err != nil {
+				return err
+			}
+		}
+	}
+
+	return mw.Close()
+}
+
+// setBufferedBody sets b as the body of the request so that it can be replayed on redirects and retries
+func setBufferedBody(r *http.Request, b []byte, contentType string) {
+	r.Header.Set("Content-Type", contentType)
+	r.Body = io.NopCloser(bytes.NewReader(b))
+	r.ContentLength = int64(len(b))
+	r.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+}
+
+// cancelOnCloseBody cancels the request context once the body is closed
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,108 @@
+package ginhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type createUserRequest struct {
+	Name string   `json:"name" form:"name"`
+	Tags []string `json:"tags" form:"tag"`
+}
+
+type createUserResponse struct {
+	ResponseMeta
+	ID string `json:"id"`
+}
+
+type uploadRequest struct {
+	Owner string         `form:"owner"`
+	File  *MultipartFile `form:"file"`
+}
+
+func newCodecTestServer(t *testing.T) *url.URL {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Location", "/users/1")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"1"}`))
+	})
+	mux.HandleFunc("/empty", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("/form", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		_, _ = w.Write([]byte(`{"id":"` + r.PostForm.Get("name") + strings.Join(r.PostForm["tag"], "") + `"}`))
+	})
+	mux.HandleFunc("/upload", func(w http.ResponseWriter, r *http.Request) {
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		b, _ := io.ReadAll(f)
+		_, _ = w.Write([]byte(r.FormValue("owner") + ":" + string(b)))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+		_, _ = w.Write([]byte("<html>bad gateway</html>"))
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func TestClient_SuccessStatusAndResponseMeta(t *testing.T) {
+	t.Parallel()
+	base := newCodecTestServer(t)
+	a := require.New(t)
+
+	res, err := NewClient[createUserRequest, createUserResponse](http.DefaultClient, http.MethodPost, base.JoinPath("/users")).
+		Endpoint()(context.Background(), &createUserRequest{Name: "foo"})
+	a.NoError(err, "201 treated as error")
+	a.Equal("1", res.ID)
+	a.Equal(http.StatusCreated, res.StatusCode)
+	a.Equal("/users/1", res.Header.Get("Location"))
+
+	_, err = NewClient[createUserRequest, createUserResponse](http.DefaultClient, http.MethodPost, base.JoinPath("/empty")).
+		Endpoint()(context.Background(), &createUserRequest{})
+	a.NoError(err, "204 treated as error")
+
+	_, err = NewClientWithCodec(http.DefaultClient, http.MethodPost, base.JoinPath("/users"), JSONRequestEncoder[createUserRequest], JSONResponseDecoder[createUserResponse], http.StatusOK).
+		Endpoint()(context.Background(), &createUserRequest{})
+	a.Error(err, "status outside success codes accepted")
+
+	_, err = NewClient[createUserRequest, createUserResponse](http.DefaultClient, http.MethodGet, base.JoinPath("/error")).
+		Endpoint()(context.Background(), &createUserRequest{})
+	errResp := &errors.ErrorResponse{}
+	a.True(errors.As(err, &errResp), "non json error not decoded")
+	a.Contains(errResp.DebugMessage, "bad gateway")
+}
+
+func TestClient_FormAndMultipart(t *testing.T) {
+	t.Parallel()
+	base := newCodecTestServer(t)
+	a := require.New(t)
+
+	res, err := NewClientWithCodec(http.DefaultClient, http.MethodPost, base.JoinPath("/form"), FormRequestEncoder[createUserRequest], JSONResponseDecoder[createUserResponse]).
+		Endpoint()(context.Background(), &createUserRequest{Name: "foo", Tags: []string{"a", "b"}})
+	a.NoError(err)
+	a.Equal("fooab", res.ID)
+
+	stream, err := NewClientWithCodec(http.DefaultClient, http.MethodPost, base.JoinPath("/upload"), MultipartRequestEncoder[uploadRequest], StreamResponseDecoder).
+		Endpoint()(context.Background(), &uploadRequest{Owner: "bar", File: &MultipartFile{FileName: "a.txt", Content: strings.NewReader("hello")}})
+	a.NoError(err)
+	defer stream.Close()
+	b, err := io.ReadAll(stream)
+	a.NoError(err, "streamed body closed before read")
+	a.Equal("bar:hello", string(b))
+}
@@ -0,0 +1,58 @@
+package ginhttp
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// errorRendererKey is the key of the ErrorRenderer in the gin.Context
+const errorRendererKey = "ginhttp.error_renderer"
+
+// ErrorRenderer writes the error response of NewGinEndpoint and the middlewares of the package,
+// aborting the rest of the handlers
+type ErrorRenderer func(c *gin.Context, errResp errors.ErrorResponse)
+
+// NewErrorRendererMiddleware returns a middleware which makes NewGinEndpoint and the middlewares of the package
+// down the chain render the error responses using renderer, JSONErrorRenderer is used otherwise.
+// It must run before the other middlewares of the package e.g. by using it first on the router.
+//
+// e.g. router.Use(NewErrorRendererMiddleware(ProblemJSONErrorRenderer))
+func NewErrorRendererMiddleware(renderer ErrorRenderer) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(errorRendererKey, renderer)
+		c.Next()
+	}
+}
+
+// renderError renders the error response using the renderer set by NewErrorRendererMiddleware, JSONErrorRenderer if none
+func renderError(c *gin.Context, errResp errors.ErrorResponse) {
+	if renderer, ok := c.Get(errorRendererKey); ok {
+		renderer.(ErrorRenderer)(c, errResp)
+		return
+	}
+
+	JSONErrorRenderer(c, errResp)
+}
+
+// JSONErrorRenderer writes the errors.ErrorResponse as JSON
+func JSONErrorRenderer(c *gin.Context, errResp errors.ErrorResponse) {
+	c.AbortWithStatusJSON(errors.GetHttpCodeFromErrorType(errResp.ErrorType), errResp)
+}
+
+// ProblemJSONErrorRenderer writes the RFC 7807 application/problem+json representation of the errors.ErrorResponse.
+// Clients accepting only application/json get the errors.ErrorResponse instead.
+func ProblemJSONErrorRenderer(c *gin.Context, errResp errors.ErrorResponse) {
+	if c.NegotiateFormat(errors.ContentTypeProblemJSON, ContentTypeJSON) == ContentTypeJSON {
+		JSONErrorRenderer(c, errResp)
+		return
+	}
+
+	status := errors.GetHttpCodeFromErrorType(errResp.ErrorType)
+	c.Header("Content-Type", errors.ContentTypeProblemJSON)
+	c.AbortWithStatusJSON(status, errors.NewProblemDetails(errResp, status, c.Request.URL.RequestURI()))
+}
+
+// abortWithError renders the error using the error renderer
+func abortWithError(c *gin.Context, err error) {
+	renderError(c, errors.ToErrorResponse(err))
+}
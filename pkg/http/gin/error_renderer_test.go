@@ -0,0 +1,63 @@
+package ginhttp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProblemJSONErrorRenderer(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/users/:id", func(c *gin.Context) {
+		errResp := errors.NewErrorResponseWithDebug("Record Not Found", "user u1 not found", errors.ErrRecordNotFoundStr)
+		errResp.ErrorDetails = map[string]string{"id": c.Param("id")}
+		ProblemJSONErrorRenderer(c, errResp)
+	})
+
+	serve := func(accept string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/users/u1?expand=roles", nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	a := require.New(t)
+	w := serve("")
+	a.Equal(http.StatusNotFound, w.Code)
+	a.Equal(errors.ContentTypeProblemJSON, w.Header().Get("Content-Type"))
+	a.JSONEq(`{
+		"type": "about:blank",
+		"title": "Record Not Found",
+		"status": 404,
+		"detail": "user u1 not found",
+		"instance": "/users/u1?expand=roles",
+		"error_type": "record not found",
+		"code": 1,
+		"id": "u1"
+	}`, w.Body.String())
+
+	err := ErrorDecoder(w.Result())
+	errResp := &errors.ErrorResponse{}
+	a.ErrorAs(err, &errResp)
+	a.Equal(errors.ErrRecordNotFoundStr, errResp.ErrorType)
+	a.Equal(errors.GetErrorCodeForErrorType(errors.ErrRecordNotFoundStr), errResp.Code)
+	a.Equal("user u1 not found", errResp.DebugMessage)
+	a.Equal(map[string]string{"id": "u1"}, errResp.ErrorDetails)
+
+	w = serve(ContentTypeJSON)
+	a.Equal(http.StatusNotFound, w.Code)
+	a.Contains(w.Header().Get("Content-Type"), ContentTypeJSON)
+	legacy := errors.ErrorResponse{}
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &legacy))
+	a.Equal(errors.ErrRecordNotFoundStr, legacy.ErrorType)
+}
@@ -6,8 +6,11 @@ import (
 
 	ginzap "github.com/gin-contrib/zap"
 	"github.com/gin-gonic/gin"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
 	ginprometheus "github.com/nitesh237/go-gin-prometheus"
 	"github.com/nitesh237/go-server-template/pkg/auth"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
 	"github.com/nitesh237/go-server-template/pkg/log"
 	"go.uber.org/fx"
 )
@@ -50,6 +53,38 @@ var (
 		}),
 		fx.Invoke(func(authenticator auth.Authenticator) {}, func(router *gin.Engine) {}),
 	)
+
+	// FxRateLimitModule rate limits the incoming requests as per cfg.Application.RateLimit.
+	// Include it after FxAuthenticationModule when callers are limited by principal.
+	FxRateLimitModule = fx.Module("gin-http-rate-limit",
+		fx.Decorate(func(router *gin.Engine, appConf *cfg.Application) *gin.Engine {
+			if appConf.RateLimit != nil {
+				router.Use(NewRateLimitMiddleware(appConf.RateLimit, nil))
+			}
+			return router
+		}),
+		fx.Invoke(func(router *gin.Engine) {}),
+	)
+
+	// FxRequestValidationModule validates the requests of the endpoints created by NewGinEndpoint
+	// using the validator provided by validatorfx.FxModule
+	FxRequestValidationModule = fx.Module("gin-http-request-validation",
+		fx.Decorate(func(router *gin.Engine, v *validator.Validate, trans ut.Translator) *gin.Engine {
+			router.Use(NewRequestValidationMiddleware(v, trans))
+			return router
+		}),
+		fx.Invoke(func(router *gin.Engine) {}),
+	)
+
+	// FxProblemDetailsModule renders the error responses as RFC 7807 application/problem+json.
+	// Include it before the modules adding the middlewares of the package e.g. FxRateLimitModule.
+	FxProblemDetailsModule = fx.Module("gin-http-problem-details",
+		fx.Decorate(func(router *gin.Engine) *gin.Engine {
+			router.Use(NewErrorRendererMiddleware(ProblemJSONErrorRenderer))
+			return router
+		}),
+		fx.Invoke(func(router *gin.Engine) {}),
+	)
 )
 
 func GinHttRouterProvider(e *gin.Engine) GinHttpRouter {
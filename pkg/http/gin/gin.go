@@ -5,7 +5,6 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	"github.com/nitesh237/go-server-template/pkg/errors"
 )
 
 type GinHttpRouter interface {
@@ -15,40 +14,35 @@ type GinHttpRouter interface {
 
 type Endpoint[req, resp any] func(ctx context.Context, req *req) (*resp, error)
 
-func NewGinEndpoint[req, resp any](ep Endpoint[req, resp]) gin.HandlerFunc {
+// NewGinEndpoint adapts the endpoint to a gin handler. The request is bound by BindRequest,
+// validated by the validator set by NewRequestValidationMiddleware, if any, and the response is written as JSON.
+// Responses embedding ResponseMeta control the status code, headers and cookies, and the ones implementing
+// ResponseWriter write the body themselves e.g. StreamBody and EventStream. Errors are converted by
+// errors.ToErrorResponse and answered with the HTTP status registered for their type, rendered by
+// the ErrorRenderer set by NewErrorRendererMiddleware, JSONErrorRenderer if none.
+// The endpoint is wrapped by the middlewares, first middleware being the outermost.
+func NewGinEndpoint[req, resp any](ep Endpoint[req, resp], middlewares ...Middleware[req, resp]) gin.HandlerFunc {
+	ep = Chain(middlewares...)(ep)
 	return func(c *gin.Context) {
 		r := new(req)
-		if err := c.ShouldBind(&r); err != nil {
-			c.JSON(errors.GetHttpCodeFromErrorType(errors.ErrInvalidArgumentStr), errors.NewErrorResponseWithDebug("Invalid Argument", err.Error(), errors.ErrInvalidArgumentStr))
+		if err := BindRequest(c, r); err != nil {
+			abortWithError(c, err)
 			return
 		}
 
+		if v := getRequestValidator(c); v != nil {
+			if err := v.validateRequest(c, r); err != nil {
+				abortWithError(c, err)
+				return
+			}
+		}
+
 		res, err := ep(c, r)
 		if err == nil {
-			c.JSON(http.StatusOK, res)
+			writeResponse(c, res)
 			return
 		}
 
-		errResp := errors.ErrorResponse{}
-		switch {
-		case errors.As(err, &errResp):
-			if errResp.Code == 0 {
-				errResp.Code = errors.GetErrorCodeForErrorType(errResp.ErrorType)
-			}
-		case errors.IsRecordNotFound(err):
-			errResp = errors.NewErrorResponseWithDebug("Record Not Found", err.Error(), errors.ErrRecordNotFoundStr)
-		case errors.Is(err, errors.ErrInvalidArgument):
-			errResp = errors.NewErrorResponseWithDebug("Invalid Argument", err.Error(), errors.ErrInvalidArgumentStr)
-		case errors.Is(err, errors.ErrAlreadyExists):
-			errResp = errors.NewErrorResponseWithDebug("Already Exists", err.Error(), errors.ErrAlreadyExistsStr)
-		case errors.Is(err, errors.ErrPermissionDenied):
-			errResp = errors.NewErrorResponseWithDebug("Permission Denied", err.Error(), errors.ErrPermissionDeniedStr)
-		case errors.Is(err, errors.ErrFailedPrecondition):
-			errResp = errors.NewErrorResponseWithDebug("Failed Precondition", err.Error(), errors.ErrFailedPreconditionStr)
-		default:
-			errResp = errors.NewErrorResponseWithDebug("Internal Server Error", err.Error(), errors.ErrInternalServerStr)
-		}
-
-		c.JSON(errors.GetHttpCodeFromErrorType(errResp.ErrorType), errResp)
+		abortWithError(c, err)
 	}
 }
@@ -0,0 +1,185 @@
+package ginhttp
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/auth"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	defaultIdempotencyKeyTTL  = 24 * time.Hour
+	defaultIdempotencyLockTTL = time.Minute
+)
+
+// unreplayedHeaders are the response headers specific to the original response, the rest are replayed
+var unreplayedHeaders = []string{"Content-Length", "Date", "Connection", "Transfer-Encoding"}
+
+// NewIdempotencyMiddleware returns a middleware which makes the route safe to retry with an Idempotency-Key header.
+// The first request with a key is executed and its response is stored, subsequent requests with the same key
+// and payload get the stored response replayed.
+//   - same key with a different payload is rejected with errors.ErrAlreadyExistsStr
+//   - same key while the first request is still executing is rejected with errors.ErrInProgressStr
+//
+// 5xx responses are not stored, so that the request can be retried with the same key.
+// A request whose key was taken over after the lock timed out does not store its response.
+// Keys are scoped to the authenticated user if the authenticator middleware runs before this middleware.
+//
+// e.g. router.POST("/orders", NewIdempotencyMiddleware(store, conf, logger), NewGinEndpoint(createOrder))
+func NewIdempotencyMiddleware(store storage.IdempotencyStore, conf *cfg.Idempotency, logger log.Logger) gin.HandlerFunc {
+	keyTTL := defaultIdempotencyKeyTTL
+	lockTimeout := defaultIdempotencyLockTTL
+	requireKey := false
+	if conf != nil {
+		if conf.KeyTTL > 0 {
+			keyTTL = conf.KeyTTL
+		}
+		if conf.LockTimeout > 0 {
+			lockTimeout = conf.LockTimeout
+		}
+		requireKey = conf.RequireKey
+	}
+
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			if requireKey {
+				abortWithErrorType(c, "Invalid Argument", fmt.Sprintf("%s header is required", IdempotencyKeyHeader), errors.ErrInvalidArgumentStr)
+				return
+			}
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			abortWithErrorType(c, "Invalid Argument", fmt.Sprintf("%s header must be at most %d characters", IdempotencyKeyHeader, maxIdempotencyKeyLength), errors.ErrInvalidArgumentStr)
+			return
+		}
+
+		if info := auth.UserFromRequest(c.Request); info != nil {
+			key = scopeIdempotencyKey(info.UserName(), key)
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithErrorType(c, "Invalid Argument", err.Error(), errors.ErrInvalidArgumentStr)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		fingerprint := getRequestFingerprint(c.Request, body)
+		record, claimed, err := store.Begin(ctx, key, fingerprint, lockTimeout, keyTTL)
+		if err != nil {
+			logger.Error(ctx, "failed to claim idempotency key", zap.Error(err))
+			abortWithErrorType(c, "Internal Server Error", err.Error(), errors.ErrInternalServerStr)
+			return
+		}
+
+		if !claimed {
+			switch {
+			case record.RequestFingerprint != fingerprint:
+				abortWithErrorType(c, "Already Exists", fmt.Sprintf("%s already used with a different request", IdempotencyKeyHeader), errors.ErrAlreadyExistsStr)
+			case record.Status == storage.IdempotencyStatusInProgress:
+				abortWithErrorType(c, "In Progress", fmt.Sprintf("request with the same %s is in progress", IdempotencyKeyHeader), errors.ErrInProgressStr)
+			default:
+				for name, values := range record.ResponseHeaders {
+					c.Writer.Header()[name] = values
+				}
+				c.Header(IdempotentReplayedHeader, "true")
+				c.Data(record.ResponseStatusCode, record.ResponseContentType, record.ResponseBody)
+				c.Abort()
+			}
+			return
+		}
+
+		completed := false
+		defer func() {
+			// release the key on failures and panics so that the caller can retry
+			if !completed {
+				if err := store.Release(context.WithoutCancel(ctx), key, record.OwnerToken); err != nil {
+					logger.Error(ctx, "failed to release idempotency key", zap.Error(err))
+				}
+			}
+		}()
+
+		w := &bodyCaptureWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = w
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		completed = true
+		headers := w.Header().Clone()
+		for _, name := range unreplayedHeaders {
+			headers.Del(name)
+		}
+		if err := store.Complete(context.WithoutCancel(ctx), key, record.OwnerToken, w.Status(), headers, w.body.Bytes()); err != nil {
+			logger.Error(ctx, "failed to store idempotent response", zap.Error(err))
+		}
+	}
+}
+
+func abortWithErrorType(c *gin.Context, msg, debugMsg string, errType errors.ErrorType) {
+	renderError(c, errors.NewErrorResponseWithDebug(msg, debugMsg, errType))
+}
+
+// getRequestFingerprint returns the hex encoded sha256 of method, url and body of the request
+// scopeIdempotencyKey prefixes the key with the user, the scoped key is hashed if it doesn't fit
+// in maxIdempotencyKeyLength so that any valid key can be stored
+func scopeIdempotencyKey(userName, key string) string {
+	scoped := userName + ":" + key
+	if len(scoped) <= maxIdempotencyKeyLength {
+		return scoped
+	}
+
+	sum := sha256.Sum256([]byte(scoped))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func getRequestFingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// bodyCaptureWriter captures the response body while writing it to the underlying writer
+type bodyCaptureWriter struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
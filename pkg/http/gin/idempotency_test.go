@@ -0,0 +1,155 @@
+package ginhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"github.com/stretchr/testify/require"
+)
+
+func newIdempotentRouter(a *require.Assertions, conf *cfg.Idempotency, handler gin.HandlerFunc) *gin.Engine {
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/orders", NewIdempotencyMiddleware(storage.NewInMemoryIdempotencyStore(), conf, logger), handler)
+	return router
+}
+
+func serveIdempotent(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestIdempotencyMiddleware_Replay(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	var calls atomic.Int32
+	router := newIdempotentRouter(a, nil, func(c *gin.Context) {
+		calls.Add(1)
+		c.Header("Location", "/orders/1")
+		c.JSON(http.StatusCreated, gin.H{"id": 1})
+	})
+
+	w := serveIdempotent(router, "k1", `{"item":"book"}`)
+	a.Equal(http.StatusCreated, w.Code)
+	a.Empty(w.Header().Get(IdempotentReplayedHeader))
+
+	replay := serveIdempotent(router, "k1", `{"item":"book"}`)
+	a.Equal(http.StatusCreated, replay.Code)
+	a.JSONEq(w.Body.String(), replay.Body.String())
+	a.Equal("/orders/1", replay.Header().Get("Location"))
+	a.Equal(w.Header().Get("Content-Type"), replay.Header().Get("Content-Type"))
+	a.Equal("true", replay.Header().Get(IdempotentReplayedHeader))
+	a.EqualValues(1, calls.Load())
+
+	// same key with a different payload
+	a.Equal(http.StatusConflict, serveIdempotent(router, "k1", `{"item":"pen"}`).Code)
+	a.EqualValues(1, calls.Load())
+
+	a.Equal(http.StatusCreated, serveIdempotent(router, "k2", `{"item":"book"}`).Code)
+	a.EqualValues(2, calls.Load())
+}
+
+func TestIdempotencyMiddleware_ServerError(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	var calls atomic.Int32
+	router := newIdempotentRouter(a, nil, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.Status(http.StatusServiceUnavailable)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	// 5xx releases the key so that the request can be retried
+	a.Equal(http.StatusServiceUnavailable, serveIdempotent(router, "k1", "{}").Code)
+	a.Equal(http.StatusCreated, serveIdempotent(router, "k1", "{}").Code)
+	a.EqualValues(2, calls.Load())
+}
+
+func TestIdempotencyMiddleware_InProgress(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	started, unblock := make(chan struct{}), make(chan struct{})
+	router := newIdempotentRouter(a, nil, func(c *gin.Context) {
+		close(started)
+		<-unblock
+		c.String(http.StatusCreated, "created")
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serveIdempotent(router, "k1", "{}") }()
+	<-started
+
+	w := serveIdempotent(router, "k1", "{}")
+	a.Equal(http.StatusConflict, w.Code)
+	a.Contains(w.Body.String(), "in progress")
+
+	close(unblock)
+	a.Equal(http.StatusCreated, (<-done).Code)
+	a.Equal("created", serveIdempotent(router, "k1", "{}").Body.String())
+}
+
+func TestIdempotencyMiddleware_ExpiredLock(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	var calls atomic.Int32
+	started, unblock := make(chan struct{}), make(chan struct{})
+	router := newIdempotentRouter(a, &cfg.Idempotency{LockTimeout: 20 * time.Millisecond}, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-unblock
+			c.String(http.StatusCreated, "first")
+			return
+		}
+		c.String(http.StatusCreated, "second")
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- serveIdempotent(router, "k1", "{}") }()
+	<-started
+	time.Sleep(30 * time.Millisecond)
+
+	// the key is taken over once the lock of the first request times out
+	a.Equal("second", serveIdempotent(router, "k1", "{}").Body.String())
+
+	// the first request neither overwrites nor releases the key it no longer owns
+	close(unblock)
+	a.Equal("first", (<-done).Body.String())
+	replay := serveIdempotent(router, "k1", "{}")
+	a.Equal("second", replay.Body.String())
+	a.Equal("true", replay.Header().Get(IdempotentReplayedHeader))
+	a.EqualValues(2, calls.Load())
+}
+
+func TestScopeIdempotencyKey(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	a.Equal("alice:k1", scopeIdempotencyKey("alice", "k1"))
+
+	// the longest valid key still fits the column once scoped
+	key := strings.Repeat("k", maxIdempotencyKeyLength)
+	scoped := scopeIdempotencyKey("alice", key)
+	a.LessOrEqual(len(scoped), maxIdempotencyKeyLength)
+	a.Equal(scoped, scopeIdempotencyKey("alice", key))
+	a.NotEqual(scoped, scopeIdempotencyKey("bob", key))
+}
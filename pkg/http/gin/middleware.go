@@ -0,0 +1,150 @@
+package ginhttp
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Middleware wraps an Endpoint to add cross cutting behaviour e.g. logging, metrics, retries etc.
+// Same middleware can be used on both the server side through NewGinEndpoint and the client side through WithMiddlewares.
+type Middleware[req, resp any] func(Endpoint[req, resp]) Endpoint[req, resp]
+
+// Chain composes the middlewares into a single middleware, first middleware being the outermost
+func Chain[req, resp any](middlewares ...Middleware[req, resp]) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		for i := len(middlewares) - 1; i >= 0; i-- {
+			next = middlewares[i](next)
+		}
+		return next
+	}
+}
+
+// LoggingMiddleware logs the outcome and the latency of every call made to the endpoint
+func LoggingMiddleware[req, resp any](logger log.Logger, name string) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		return func(ctx context.Context, r *req) (*resp, error) {
+			start := time.Now()
+			res, err := next(ctx, r)
+			if err != nil {
+				logger.Error(ctx, "endpoint call failed", zap.String("endpoint", name), zap.Duration("latency", time.Since(start)), zap.Error(err))
+				return res, err
+			}
+
+			logger.Debug(ctx, "endpoint call succeeded", zap.String("endpoint", name), zap.Duration("latency", time.Since(start)))
+			return res, nil
+		}
+	}
+}
+
+// EndpointMetrics holds the prometheus collectors shared by all the endpoints using MetricsMiddleware
+type EndpointMetrics struct {
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+}
+
+// NewEndpointMetrics creates and registers the endpoint collectors with the registerer
+func NewEndpointMetrics(registerer prometheus.Registerer, namespace string) (*EndpointMetrics, error) {
+	m := &EndpointMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "endpoint_requests_total",
+			Help:      "Total number of calls made to the endpoint partitioned by outcome.",
+		}, []string{"endpoint", "result"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "endpoint_request_duration_seconds",
+			Help:      "Latency of the calls made to the endpoint.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"endpoint"}),
+	}
+
+	for _, c := range []prometheus.Collector{m.requests, m.latency} {
+		if err := registerer.Register(c); err != nil {
+			return nil, errors.Wrap(err, "failed to register endpoint metrics")
+		}
+	}
+
+	return m, nil
+}
+
+// MetricsMiddleware records the count and the latency of every call made to the endpoint
+func MetricsMiddleware[req, resp any](metrics *EndpointMetrics, name string) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		return func(ctx context.Context, r *req) (*resp, error) {
+			start := time.Now()
+			res, err := next(ctx, r)
+			metrics.latency.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+			result := "success"
+			if err != nil {
+				result = "error"
+			}
+			metrics.requests.WithLabelValues(name, result).Inc()
+
+			return res, err
+		}
+	}
+}
+
+// TimeoutMiddleware limits the time of every call made to the endpoint.
+// Calls exceeding the timeout fail with errors.ErrTimedOut.
+// NOTE: not suitable for client endpoints returning StreamBody as the context is canceled once the call returns,
+// use WithTimeout instead.
+func TimeoutMiddleware[req, resp any](timeout time.Duration) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		return func(ctx context.Context, r *req) (*resp, error) {
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+
+			res, err := next(ctx, r)
+			if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, errors.Wrap(errors.ErrTimedOut, "endpoint timed out after %s: %s", timeout, err.Error())
+			}
+
+			return res, err
+		}
+	}
+}
+
+// RecoveryMiddleware recovers from the panics in the endpoint and returns an errors.ErrorResponse instead
+func RecoveryMiddleware[req, resp any](logger log.Logger) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		return func(ctx context.Context, r *req) (res *resp, err error) {
+			defer func() {
+				if p := recover(); p != nil {
+					logger.Error(ctx, "endpoint panic", zap.Any("panic", p), zap.ByteString("stack", debug.Stack()))
+					res = nil
+					err = errors.NewErrorResponseWithDebug("Internal Server Error", fmt.Sprintf("panic: %v", p), errors.ErrInternalServerStr)
+				}
+			}()
+
+			return next(ctx, r)
+		}
+	}
+}
+
+// ValidationMiddleware validates the request using the validator before calling the endpoint.
+// Invalid requests fail with errors.ErrInvalidArgumentStr having the field errors translated by trans in ErrorDetails,
+// e.g. the validator and the translator provided by validatorfx.FxModule. Non struct requests are not validated.
+func ValidationMiddleware[req, resp any](v *validator.Validate, trans ut.Translator) Middleware[req, resp] {
+	return func(next Endpoint[req, resp]) Endpoint[req, resp] {
+		return func(ctx context.Context, r *req) (*resp, error) {
+			err := v.StructCtx(ctx, r)
+			invalidValidationErr := &validator.InvalidValidationError{}
+			if err != nil && !errors.As(err, &invalidValidationErr) {
+				return nil, newValidationErrorResponse(err, trans)
+			}
+
+			return next(ctx, r)
+		}
+	}
+}
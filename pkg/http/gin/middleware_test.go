@@ -0,0 +1,82 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/validatorfx"
+	"github.com/stretchr/testify/require"
+)
+
+type greetRequest struct {
+	Name string `json:"name" validate:"required"`
+}
+
+type greetResponse struct {
+	Message string `json:"message"`
+}
+
+func newTestLogger() log.Logger {
+	zapLogger, _ := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	return zapLogger
+}
+
+func TestNewGinEndpoint_Middlewares(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	ep := func(ctx context.Context, r *greetRequest) (*greetResponse, error) {
+		if r.Name == "panic" {
+			panic("boom")
+		}
+		return &greetResponse{Message: "hello " + r.Name}, nil
+	}
+
+	a := require.New(t)
+	v, trans, err := validatorfx.NewValidator()
+	a.NoError(err)
+
+	router := gin.New()
+	router.POST("/greet", NewGinEndpoint(ep,
+		RecoveryMiddleware[greetRequest, greetResponse](newTestLogger()),
+		ValidationMiddleware[greetRequest, greetResponse](v, trans),
+	))
+
+	serveJSON := func(body string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/greet", strings.NewReader(body))
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serveJSON(`{"name":"foo"}`)
+	a.Equal(http.StatusOK, w.Code)
+	a.JSONEq(`{"message":"hello foo"}`, w.Body.String())
+
+	w = serveJSON(`{}`)
+	a.Equal(http.StatusBadRequest, w.Code, "validation middleware not applied")
+	errResp := errors.ErrorResponse{}
+	a.NoError(json.Unmarshal(w.Body.Bytes(), &errResp))
+	a.Equal(map[string]string{"name": "name is a required field"}, errResp.ErrorDetails)
+	a.Equal(http.StatusInternalServerError, serveJSON(`{"name":"panic"}`).Code, "panic not recovered")
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Parallel()
+	ep := Chain(TimeoutMiddleware[greetRequest, greetResponse](time.Millisecond))(func(ctx context.Context, r *greetRequest) (*greetResponse, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	_, err := ep(context.Background(), &greetRequest{})
+	require.True(t, errors.Is(err, errors.ErrTimedOut), "timeout not mapped to ErrTimedOut")
+}
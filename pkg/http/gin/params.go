@@ -0,0 +1,247 @@
+package ginhttp
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+const (
+	pathTag   = "path"
+	queryTag  = "query"
+	headerTag = "header"
+)
+
+var (
+	paramTags         = []string{pathTag, queryTag, headerTag}
+	pathParamTemplate = regexp.MustCompile(`\{([^{}/]+)\}`)
+)
+
+// BindRequestParams sets the fields of the request tagged with `path`, `query` and `header` on the HTTP request.
+//   - `path:"id"` replaces {id} in the URL path of the target e.g. /users/{id}
+//   - `query:"expand"` adds the expand query param, slices add a value per element
+//   - `header:"X-Tenant"` sets the X-Tenant header, slices add a value per element
+//
+// `omitempty` option skips zero values for query and header e.g. `query:"expand,omitempty"`.
+// Values are formatted using encoding.TextMarshaler if implemented, fmt.Sprint otherwise.
+func BindRequestParams(r *http.Request, request any) error {
+	rv := reflect.Indirect(reflect.ValueOf(request))
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+
+	unbound := map[string]bool{}
+	for _, match := range pathParamTemplate.FindAllStringSubmatch(r.URL.Path, -1) {
+		unbound[match[1]] = true
+	}
+
+	escapedPath := r.URL.EscapedPath()
+	query := r.URL.Query()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fv := rv.Field(i)
+		if name, ok := field.Tag.Lookup(pathTag); ok {
+			val, err := formatParamValue(fv)
+			if err != nil {
+				return errors.Wrap(err, "failed to format path param %s", name)
+			}
+			if val == "" {
+				return errors.Wrap(errors.ErrInvalidArgument, "empty path param %s", name)
+			}
+			escapedPath = strings.ReplaceAll(escapedPath, url.PathEscape("{"+name+"}"), url.PathEscape(val))
+			delete(unbound, name)
+		}
+
+		if tag, ok := field.Tag.Lookup(queryTag); ok {
+			name, omitEmpty := parseParamTag(tag)
+			vals, err := formatParamValues(fv, omitEmpty)
+			if err != nil {
+				return errors.Wrap(err, "failed to format query param %s", name)
+			}
+			for _, val := range vals {
+				query.Add(name, val)
+			}
+		}
+
+		if tag, ok := field.Tag.Lookup(headerTag); ok {
+			name, omitEmpty := parseParamTag(tag)
+			vals, err := formatParamValues(fv, omitEmpty)
+			if err != nil {
+				return errors.Wrap(err, "failed to format header %s", name)
+			}
+			for _, val := range vals {
+				r.Header.Add(name, val)
+			}
+		}
+	}
+
+	if len(unbound) > 0 {
+		return errors.Wrap(errors.ErrInvalidArgument, "path params %v not bound in %s", slices.Sorted(maps.Keys(unbound)), r.URL.Path)
+	}
+
+	path, err := url.PathUnescape(escapedPath)
+	if err != nil {
+		return err
+	}
+
+	r.URL.Path = path
+	r.URL.RawPath = escapedPath
+	r.URL.RawQuery = query.Encode()
+	return nil
+}
+
+// hasNoBody returns true for the methods whose requests must not have a body
+func hasNoBody(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// marshalJSONBody JSON-encodes the request excluding the fields bound by BindRequestParams
+func marshalJSONBody(request any) ([]byte, error) {
+	b, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	excluded := getParamFieldJSONNames(request)
+	if len(excluded) == 0 {
+		return b, nil
+	}
+
+	m := map[string]json.RawMessage{}
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+
+	for _, name := range excluded {
+		delete(m, name)
+	}
+
+	return json.Marshal(m)
+}
+
+func getParamFieldJSONNames(request any) []string {
+	rt := reflect.TypeOf(request)
+	for rt != nil && rt.Kind() == reflect.Pointer {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var names []string
+	for i := 0; i < rt.NumField(); i++ {
+		field := rt.Field(i)
+		if !isParamField(field) {
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = field.Name
+		}
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func isParamField(field reflect.StructField) bool {
+	for _, tag := range paramTags {
+		if _, ok := field.Tag.Lookup(tag); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseParamTag(tag string) (name string, omitEmpty bool) {
+	parts := strings.Split(tag, ",")
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+
+	return parts[0], omitEmpty
+}
+
+// formatParamValues formats the value of the field, slices result in a value per element
+func formatParamValues(fv reflect.Value, omitEmpty bool) ([]string, error) {
+	if omitEmpty && fv.IsZero() {
+		return nil, nil
+	}
+
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return nil, nil
+		}
+		fv = fv.Elem()
+	}
+
+	if fv.Kind() != reflect.Slice || fv.Type().Elem().Kind() == reflect.Uint8 {
+		val, err := formatParamValue(fv)
+		if err != nil {
+			return nil, err
+		}
+		return []string{val}, nil
+	}
+
+	vals := make([]string, 0, fv.Len())
+	for j := 0; j < fv.Len(); j++ {
+		val, err := formatParamValue(fv.Index(j))
+		if err != nil {
+			return nil, err
+		}
+		vals = append(vals, val)
+	}
+
+	return vals, nil
+}
+
+func formatParamValue(fv reflect.Value) (string, error) {
+	if fv.Kind() == reflect.Pointer {
+		if fv.IsNil() {
+			return "", nil
+		}
+		fv = fv.Elem()
+	}
+
+	// pointer receivers are considered as well, when the value is addressable
+	v := fv.Interface()
+	if fv.CanAddr() {
+		v = fv.Addr().Interface()
+	}
+
+	if tm, ok := v.(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(b), nil
+	}
+
+	return fmt.Sprint(fv.Interface()), nil
+}
@@ -0,0 +1,66 @@
+package ginhttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type getUserRequest struct {
+	ID     string   `path:"id"`
+	Expand []string `query:"expand"`
+	Limit  int      `query:"limit,omitempty"`
+	Tenant string   `header:"X-Tenant"`
+	Name   string   `json:"name"`
+}
+
+type echoResponse struct {
+	Method string              `json:"method"`
+	URI    string              `json:"uri"`
+	Tenant string              `json:"tenant"`
+	Body   map[string]any      `json:"body"`
+	Query  map[string][]string `json:"query"`
+}
+
+func newEchoServer(t *testing.T) *url.URL {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		res := echoResponse{Method: r.Method, URI: r.URL.EscapedPath(), Tenant: r.Header.Get("X-Tenant"), Query: r.URL.Query()}
+		_ = json.Unmarshal(b, &res.Body)
+		_ = json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	u, _ := url.Parse(srv.URL)
+	return u
+}
+
+func TestClient_BindRequestParams(t *testing.T) {
+	t.Parallel()
+	base := newEchoServer(t)
+	a := require.New(t)
+
+	res, err := NewClient[getUserRequest, echoResponse](http.DefaultClient, http.MethodPost, base.JoinPath("/users/{id}")).
+		Endpoint()(context.Background(), &getUserRequest{ID: "a/b", Expand: []string{"x", "y"}, Tenant: "t1", Name: "foo"})
+	a.NoError(err)
+	a.Equal("/users/a%2Fb", res.URI, "path param not bound")
+	a.Equal([]string{"x", "y"}, res.Query["expand"], "query param not bound")
+	a.NotContains(res.Query, "limit", "omitempty not honoured")
+	a.Equal("t1", res.Tenant, "header not bound")
+	a.Equal(map[string]any{"name": "foo"}, res.Body, "params not excluded from body")
+
+	res, err = NewClient[getUserRequest, echoResponse](http.DefaultClient, http.MethodGet, base.JoinPath("/users/{id}")).
+		Endpoint()(context.Background(), &getUserRequest{ID: "1", Limit: 10, Name: "foo"})
+	a.NoError(err)
+	a.Nil(res.Body, "body sent for GET")
+	a.Equal([]string{"10"}, res.Query["limit"])
+
+	_, err = NewClient[getUserRequest, echoResponse](http.DefaultClient, http.MethodGet, base.JoinPath("/users/{id}/{version}")).
+		Endpoint()(context.Background(), &getUserRequest{ID: "1"})
+	a.Error(err, "unbound path param accepted")
+}
@@ -0,0 +1,165 @@
+package ginhttp
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/auth"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	httppkg "github.com/nitesh237/go-server-template/pkg/http"
+	"golang.org/x/time/rate"
+)
+
+const (
+	defaultIdleKeyTTL = 10 * time.Minute
+)
+
+// RateLimitKeyFunc identifies the caller of the request on which the rate limit is applied
+type RateLimitKeyFunc func(c *gin.Context) string
+
+// ClientIPRateLimitKey identifies the caller by client IP
+func ClientIPRateLimitKey(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// PrincipalRateLimitKey identifies the caller by the user attached by the authenticator middleware
+// and falls back to client IP for unauthenticated requests
+func PrincipalRateLimitKey(c *gin.Context) string {
+	if info := auth.UserFromRequest(c.Request); info != nil {
+		return "principal:" + info.UserName()
+	}
+
+	return ClientIPRateLimitKey(c)
+}
+
+// GetRateLimitKeyFunc returns the RateLimitKeyFunc for the key type, defaults to ClientIPRateLimitKey
+func GetRateLimitKeyFunc(keyType cfg.RateLimitKeyType) RateLimitKeyFunc {
+	switch keyType {
+	case cfg.RateLimitKeyPrincipal:
+		return PrincipalRateLimitKey
+	case cfg.RateLimitKeyClientIP:
+		fallthrough
+	default:
+		return ClientIPRateLimitKey
+	}
+}
+
+// NewRateLimitMiddleware returns a middleware which enforces a token bucket per caller as identified by keyFunc.
+// If keyFunc is nil, callers are identified as per conf.KeyType.
+// Requests over the limit are rejected with 429 and a Retry-After header.
+// NOTE: principal based limits need the middleware to be registered after the authenticator middleware.
+func NewRateLimitMiddleware(conf *cfg.ServerRateLimit, keyFunc RateLimitKeyFunc) gin.HandlerFunc {
+	if keyFunc == nil {
+		keyFunc = GetRateLimitKeyFunc(conf.KeyType)
+	}
+
+	ttl := conf.IdleKeyTTL
+	if ttl <= 0 {
+		ttl = defaultIdleKeyTTL
+	}
+
+	var defaultLimiter *keyedRateLimiter
+	if conf.RateLimit != nil {
+		defaultLimiter = newKeyedRateLimiter(conf.RateLimit, ttl)
+	}
+
+	routeLimiters := map[string]*keyedRateLimiter{}
+	for key, rl := range conf.RouteRateLimitsList.GetRouteRateLimitsMap() {
+		if rl.RateLimit != nil {
+			routeLimiters[key] = newKeyedRateLimiter(rl.RateLimit, ttl)
+		}
+	}
+
+	getLimiter := func(c *gin.Context) *keyedRateLimiter {
+		if l, ok := routeLimiters[cfg.GetEndpointKey(c.Request.Method, c.FullPath())]; ok {
+			return l
+		}
+		if l, ok := routeLimiters[cfg.GetEndpointKey("", c.FullPath())]; ok {
+			return l
+		}
+		return defaultLimiter
+	}
+
+	return func(c *gin.Context) {
+		// By pass rate limit for health check
+		if c.Request.URL.Path == "/health" || c.Request.URL.Path == "/metrics" {
+			c.Next()
+			return
+		}
+
+		limiter := getLimiter(c)
+		if limiter == nil {
+			c.Next()
+			return
+		}
+
+		ok, retryAfter := limiter.allow(keyFunc(c), time.Now())
+		if !ok {
+			retryAfterSecs := int(math.Ceil(retryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(retryAfterSecs))
+			renderError(c, errors.NewErrorResponseWithDebug("Too Many Requests", fmt.Sprintf("rate limit exceeded, retry after %ds", retryAfterSecs), errors.ErrResourceExhaustedStr))
+			return
+		}
+		c.Next()
+	}
+}
+
+// keyedRateLimiter maintains a token bucket per key and evicts the buckets not used for ttl
+type keyedRateLimiter struct {
+	conf *cfg.RateLimit
+	ttl  time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+func newKeyedRateLimiter(conf *cfg.RateLimit, ttl time.Duration) *keyedRateLimiter {
+	return &keyedRateLimiter{
+		conf:      conf,
+		ttl:       ttl,
+		buckets:   map[string]*bucket{},
+		lastSweep: time.Now(),
+	}
+}
+
+// allow takes a token from the bucket of the key. If the bucket is empty,
+// it returns false along with the duration after which a token will be available.
+func (k *keyedRateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if now.Sub(k.lastSweep) > k.ttl {
+		for bk, b := range k.buckets {
+			if now.Sub(b.lastSeen) > k.ttl {
+				delete(k.buckets, bk)
+			}
+		}
+		k.lastSweep = now
+	}
+
+	b, ok := k.buckets[key]
+	if !ok {
+		b = &bucket{limiter: httppkg.NewTokenBucket(k.conf)}
+		k.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+
+	return true, 0
+}
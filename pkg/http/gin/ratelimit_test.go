@@ -0,0 +1,77 @@
+package ginhttp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func newRateLimitedRouter(conf *cfg.ServerRateLimit) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewRateLimitMiddleware(conf, nil))
+	router.GET("/users/:id", func(c *gin.Context) { c.Status(http.StatusOK) })
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func serve(router *gin.Engine, path, remoteAddr string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitMiddleware_ClientIP(t *testing.T) {
+	t.Parallel()
+	router := newRateLimitedRouter(&cfg.ServerRateLimit{
+		RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.01, Burst: 1},
+	})
+	a := require.New(t)
+	a.Equal(http.StatusOK, serve(router, "/orders", "10.0.0.1:1234").Code, "first request rejected")
+	w := serve(router, "/orders", "10.0.0.1:1234")
+	a.Equal(http.StatusTooManyRequests, w.Code, "second request not rejected")
+	a.NotEmpty(w.Header().Get("Retry-After"), "Retry-After header not set")
+	a.Equal(http.StatusOK, serve(router, "/orders", "10.0.0.2:1234").Code, "limit shared across callers")
+}
+
+func TestRateLimitMiddleware_RouteOverride(t *testing.T) {
+	t.Parallel()
+	router := newRateLimitedRouter(&cfg.ServerRateLimit{
+		RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.01, Burst: 1},
+		RouteRateLimitsList: cfg.RouteRateLimitsList{
+			{Method: http.MethodGet, Path: "/users/:id", RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.01, Burst: 2}},
+		},
+	})
+	a := require.New(t)
+	a.Equal(http.StatusOK, serve(router, "/users/1", "10.0.0.1:1234").Code, "first request rejected")
+	a.Equal(http.StatusOK, serve(router, "/users/2", "10.0.0.1:1234").Code, "route override not applied")
+	a.Equal(http.StatusTooManyRequests, serve(router, "/users/3", "10.0.0.1:1234").Code, "route limit not enforced")
+}
+
+func TestRateLimitMiddleware_ErrorRenderer(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewErrorRendererMiddleware(ProblemJSONErrorRenderer))
+	router.Use(NewRateLimitMiddleware(&cfg.ServerRateLimit{RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.01, Burst: 1}}, nil))
+	router.GET("/orders", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	a := require.New(t)
+	a.Equal(http.StatusOK, serve(router, "/orders", "10.0.0.1:1234").Code, "first request rejected")
+	w := serve(router, "/orders", "10.0.0.1:1234")
+	a.Equal(http.StatusTooManyRequests, w.Code, "second request not rejected")
+	a.Equal(errors.ContentTypeProblemJSON, w.Header().Get("Content-Type"), "error renderer not applied")
+
+	// the default renderer is used without the middleware
+	router = newRateLimitedRouter(&cfg.ServerRateLimit{RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.01, Burst: 1}})
+	serve(router, "/orders", "10.0.0.1:1234")
+	w = serve(router, "/orders", "10.0.0.1:1234")
+	a.Contains(w.Header().Get("Content-Type"), ContentTypeJSON, "default error renderer not applied")
+}
@@ -0,0 +1,126 @@
+package ginhttp
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/gin-contrib/sse"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	ContentTypeEventStream = "text/event-stream"
+)
+
+// ResponseWriter is implemented by the responses which write the body of the HTTP response themselves
+// e.g. file downloads or server sent events. statusCode is 200 unless set through ResponseMeta.
+type ResponseWriter interface {
+	WriteResponse(c *gin.Context, statusCode int)
+}
+
+// writeResponse writes the status code, the headers except unforwardedHeaders and the cookies of the ResponseMeta
+// if the response embeds it.
+// Body is written by ResponseWriter if implemented, JSON otherwise. No body is written for 204 and 304.
+func writeResponse[resp any](c *gin.Context, res *resp) {
+	if res == nil {
+		c.JSON(http.StatusOK, res)
+		return
+	}
+
+	statusCode := http.StatusOK
+	if m, ok := any(res).(responseMetaGetter); ok {
+		meta := m.GetResponseMeta()
+		for key, vals := range meta.Header {
+			if _, ok := unforwardedHeaders[http.CanonicalHeaderKey(key)]; ok {
+				continue
+			}
+			for _, val := range vals {
+				c.Writer.Header().Add(key, val)
+			}
+		}
+		for _, cookie := range meta.Cookies {
+			http.SetCookie(c.Writer, cookie)
+		}
+		if meta.StatusCode != 0 {
+			statusCode = meta.StatusCode
+		}
+	}
+
+	if w, ok := any(res).(ResponseWriter); ok {
+		w.WriteResponse(c, statusCode)
+		return
+	}
+
+	c.JSON(statusCode, res)
+}
+
+// NewFileResponse creates a StreamBody downloading the content as an attachment named fileName.
+// size is the length of the content, -1 if unknown.
+func NewFileResponse(fileName, contentType string, content io.Reader, size int64) *StreamBody {
+	s := &StreamBody{
+		Body:          content,
+		ContentType:   contentType,
+		ContentLength: size,
+	}
+	s.SetHeader("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": fileName}))
+	return s
+}
+
+func (s *StreamBody) WriteResponse(c *gin.Context, statusCode int) {
+	defer s.Close()
+
+	contentType := s.ContentType
+	if contentType == "" {
+		contentType = ContentTypeOctet
+	}
+
+	contentLength := s.ContentLength
+	if contentLength == 0 {
+		contentLength = -1
+	}
+
+	c.DataFromReader(statusCode, contentLength, contentType, s.Body, nil)
+}
+
+// ServerSentEvent is an event sent by EventStream, Data is JSON-encoded unless it's a string
+type ServerSentEvent struct {
+	Event string
+	ID    string
+	Retry uint
+	Data  any
+}
+
+// EventStream streams the events as text/event-stream until Events is closed or the client goes away.
+// The producer must stop sending once the context passed to the endpoint is done.
+type EventStream struct {
+	ResponseMeta
+	Events <-chan ServerSentEvent
+}
+
+func (s *EventStream) WriteResponse(c *gin.Context, statusCode int) {
+	c.Header("Content-Type", ContentTypeEventStream)
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Status(statusCode)
+	c.Writer.WriteHeaderNow()
+	c.Writer.Flush()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case e, ok := <-s.Events:
+			if !ok {
+				return
+			}
+			c.Render(-1, sse.Event{
+				Event: e.Event,
+				Id:    e.ID,
+				Retry: e.Retry,
+				Data:  e.Data,
+			})
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package ginhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/require"
+)
+
+type createdUserResponse struct {
+	ResponseMeta
+	ID string `json:"id"`
+}
+
+func TestNewGinEndpoint_Responses(t *testing.T) {
+	t.Parallel()
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users", NewGinEndpoint(func(ctx context.Context, _ *struct{}) (*createdUserResponse, error) {
+		res := &createdUserResponse{ResponseMeta: ResponseMeta{StatusCode: http.StatusCreated}, ID: "u1"}
+		res.SetHeader("Location", "/users/u1")
+		res.SetCookie(&http.Cookie{Name: "session", Value: "s1"})
+		return res, nil
+	}))
+	router.DELETE("/users/:id", NewGinEndpoint(func(ctx context.Context, _ *struct{}) (*ResponseMeta, error) {
+		return &ResponseMeta{StatusCode: http.StatusNoContent}, nil
+	}))
+	router.GET("/export", NewGinEndpoint(func(ctx context.Context, _ *struct{}) (*StreamBody, error) {
+		return NewFileResponse("users.csv", "text/csv", strings.NewReader("id\nu1\n"), 6), nil
+	}))
+	router.GET("/events", NewGinEndpoint(func(ctx context.Context, _ *struct{}) (*EventStream, error) {
+		events := make(chan ServerSentEvent, 2)
+		events <- ServerSentEvent{Event: "created", ID: "1", Data: "u1"}
+		events <- ServerSentEvent{Event: "created", ID: "2", Data: map[string]string{"id": "u2"}}
+		close(events)
+		return &EventStream{Events: events}, nil
+	}))
+
+	serve := func(method, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(method, path, nil))
+		return w
+	}
+
+	a := require.New(t)
+	w := serve(http.MethodPost, "/users")
+	a.Equal(http.StatusCreated, w.Code)
+	a.Equal("/users/u1", w.Header().Get("Location"))
+	a.Equal("session=s1", w.Header().Get("Set-Cookie"))
+	a.JSONEq(`{"id":"u1"}`, w.Body.String())
+
+	w = serve(http.MethodDelete, "/users/u1")
+	a.Equal(http.StatusNoContent, w.Code)
+	a.Empty(w.Body.String())
+
+	w = serve(http.MethodGet, "/export")
+	a.Equal(http.StatusOK, w.Code)
+	a.Equal("text/csv", w.Header().Get("Content-Type"))
+	a.Equal(`attachment; filename=users.csv`, w.Header().Get("Content-Disposition"))
+	a.Equal("id\nu1\n", w.Body.String())
+
+	w = serve(http.MethodGet, "/events")
+	a.Equal(http.StatusOK, w.Code)
+	a.Equal(ContentTypeEventStream, w.Header().Get("Content-Type"))
+	a.Equal("id:1\nevent:created\ndata:u1\n\nid:2\nevent:created\ndata:{\"id\":\"u2\"}\n\n", w.Body.String())
+}
+
+func TestNewGinEndpoint_ForwardsClientResponse(t *testing.T) {
+	t.Parallel()
+	downstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "s1"})
+		w.Header().Set("Location", "/users/a-long-downstream-id")
+		w.Header().Set("Content-Type", ContentTypeJSON)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"a-long-downstream-id"}`))
+	}))
+	t.Cleanup(downstream.Close)
+	tgt, _ := url.Parse(downstream.URL)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/users", NewGinEndpoint(func(ctx context.Context, _ *struct{}) (*createdUserResponse, error) {
+		res, err := NewClient[struct{}, createdUserResponse](http.DefaultClient, http.MethodPost, tgt).Endpoint()(ctx, &struct{}{})
+		if err != nil {
+			return nil, err
+		}
+		res.ID = "u1"
+		return res, nil
+	}))
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/users", nil))
+
+	a := require.New(t)
+	a.Equal(http.StatusCreated, w.Code)
+	a.Equal("/users/a-long-downstream-id", w.Header().Get("Location"))
+	a.Equal([]string{"session=s1"}, w.Header().Values("Set-Cookie"), "cookies not set exactly once")
+	a.Empty(w.Header().Get("Content-Length"), "stale content length forwarded")
+	a.Empty(w.Header().Get("Date"))
+	a.JSONEq(`{"id":"u1"}`, w.Body.String())
+}
@@ -1,101 +1,94 @@
 package ginhttp
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
-	"io"
 	"net/http"
 	"net/url"
 
 	"github.com/hashicorp/go-retryablehttp"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
 )
 
-// EncodeRequestFunc encodes the passed request object into the HTTP request
-// object. It's designed to be used in HTTP clients, for client-side
-// endpoints. One straightforward EncodeRequestFunc could be something that JSON
-// encodes the object directly to the request body.
+// EncodeRetryableRequestFunc encodes the passed request object into the retryable HTTP request object.
+//
+// Deprecated: use EncodeRequestFunc with WithCodec, or pass it to WithRetryableRequestEncoder.
 type EncodeRetryableRequestFunc[req any] func(context.Context, *retryablehttp.Request, *req) error
 
-// CreateRequestFunc creates an outgoing HTTP request based on the passed
-// request object. It's designed to be used in HTTP clients, for client-side
-// endpoints. It's a more powerful version of EncodeRequestFunc, and can be used
-// if more fine-grained control of the HTTP request is required.
+// CreateRetryableRequestFunc creates an outgoing retryable HTTP request based on the passed request object.
+//
+// Deprecated: use CreateRequestFunc with WithCreateRequest, or pass it to WithCreateRetryableRequest.
 type CreateRetryableRequestFunc[req any] func(context.Context, *req) (*retryablehttp.Request, error)
 
-// encodeHttpGenericRequest is a transport/http.EncodeRequestFunc that
-// SON-encodes any request to the request body. Primarily useful in a client.
-func encodeHttpGenericRetryableRequest[req any](_ context.Context, r *retryablehttp.Request, request *req) error {
-	b, err := json.Marshal(request)
-	if err != nil {
-		return err
-	}
-
-	r.Body = io.NopCloser(bytes.NewReader(b))
-
-	return nil
-}
-
-type RetryableClient[req, resp any] struct {
-	client *retryablehttp.Client
-	req    CreateRetryableRequestFunc[req]
-	dec    DecodeResponseFunc[resp]
-}
-
-// NewClient constructs a usable Client for a single remote method.
-func NewRetryableClient[req, resp any](client *retryablehttp.Client, method string, tgt *url.URL) *RetryableClient[req, resp] {
-	return &RetryableClient[req, resp]{
-		client: client,
-		req:    defaultCreateRetryableRequestFunc(method, tgt, encodeHttpGenericRetryableRequest[req]),
-		dec:    genericHttpResponseDecoder[resp],
+// WithRetryableRequestEncoder encodes the request with enc, the response is still decoded as per the other options.
+//
+// Deprecated: use WithCodec with an EncodeRequestFunc.
+func WithRetryableRequestEncoder[req, resp any](enc EncodeRetryableRequestFunc[req]) ClientOption[req, resp] {
+	return func(c *Client[req, resp]) {
+		c.enc = func(ctx context.Context, httpReq *http.Request, request *req) error {
+			retryableReq, err := retryablehttp.FromRequest(httpReq)
+			if err != nil {
+				return err
+			}
+
+			if err = enc(ctx, retryableReq, request); err != nil {
+				return err
+			}
+
+			return setRetryableRequestBody(retryableReq)
+		}
 	}
 }
 
-func defaultCreateRetryableRequestFunc[req any](method string, target *url.URL, enc EncodeRetryableRequestFunc[req]) CreateRetryableRequestFunc[req] {
-	return func(ctx context.Context, request *req) (*retryablehttp.Request, error) {
-		r, err := retryablehttp.NewRequest(method, target.String(), nil)
+// WithCreateRetryableRequest creates the outgoing HTTP request with create.
+//
+// Deprecated: use WithCreateRequest with a CreateRequestFunc.
+func WithCreateRetryableRequest[req, resp any](create CreateRetryableRequestFunc[req]) ClientOption[req, resp] {
+	return WithCreateRequest[req, resp](func(ctx context.Context, request *req) (*http.Request, error) {
+		retryableReq, err := create(ctx, request)
 		if err != nil {
 			return nil, err
 		}
 
-		if err = enc(ctx, r, request); err != nil {
+		if err = setRetryableRequestBody(retryableReq); err != nil {
 			return nil, err
 		}
 
-		return r, nil
-	}
+		return retryableReq.Request, nil
+	})
 }
 
-// Endpoint returns a usable Go kit endpoint that calls the remote HTTP endpoint.
-func (c RetryableClient[req, resp]) Endpoint() Endpoint[req, resp] {
-	return func(ctx context.Context, r *req) (*resp, error) {
-		ctx, cancel := context.WithCancel(ctx)
-
-		var (
-			resp *http.Response
-			err  error
-		)
-
-		req, err := c.req(ctx, r)
-		if err != nil {
-			cancel()
-			return nil, err
-		}
+// setRetryableRequestBody sets the body of the retryable request on its HTTP request, unless it's already set
+// as the retryable request keeps the body set through SetBody to itself
+func setRetryableRequestBody(retryableReq *retryablehttp.Request) error {
+	if retryableReq.Body != nil || retryableReq.GetBody == nil {
+		return nil
+	}
 
-		resp, err = c.client.Do(req.WithContext(ctx))
-		if err != nil {
-			cancel()
-			return nil, err
-		}
+	body, err := retryableReq.GetBody()
+	if err != nil {
+		return err
+	}
 
-		defer resp.Body.Close()
-		defer cancel()
+	retryableReq.Body = body
+	return nil
+}
 
-		response, err := c.dec(ctx, resp)
-		if err != nil {
-			return nil, err
-		}
+// RetryableClient is a Client which sends the requests through a retryable client
+type RetryableClient[req, resp any] struct {
+	*Client[req, resp]
+}
 
-		return response, nil
+// NewRetryableClient constructs a usable RetryableClient for a single remote method.
+func NewRetryableClient[req, resp any](client *retryablehttp.Client, method string, tgt *url.URL, opts ...ClientOption[req, resp]) *RetryableClient[req, resp] {
+	opts = append([]ClientOption[req, resp]{WithRetryableClient[req, resp](client)}, opts...)
+	return &RetryableClient[req, resp]{
+		Client: NewClientWithOptions(nil, method, tgt, opts...),
 	}
 }
+
+// NewLimitedRetryableClient constructs a usable RetryableClient for a single remote method
+// which rate limits and bulkheads every call made through the endpoint as per limits.
+// Limits are acquired once per call and are held across the retries.
+func NewLimitedRetryableClient[req, resp any](client *retryablehttp.Client, method string, tgt *url.URL, limits *cfg.Limits) *RetryableClient[req, resp] {
+	return NewRetryableClient(client, method, tgt, WithLimits[req, resp](limits))
+}
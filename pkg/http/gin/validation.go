@@ -0,0 +1,115 @@
+package ginhttp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/validatorfx"
+)
+
+// requestValidatorKey is the key of the validator in the gin.Context
+const requestValidatorKey = "ginhttp.request_validator"
+
+var (
+	// bindingTranslators are the translators of the errors of gin binding validator, configured once per translator
+	bindingTranslators   = map[ut.Translator]ut.Translator{}
+	bindingTranslatorsMu sync.Mutex
+)
+
+type requestValidator struct {
+	validate *validator.Validate
+	trans    ut.Translator
+	// bindingTrans translates the errors of `binding` tags validated by gin
+	bindingTrans ut.Translator
+}
+
+// NewRequestValidationMiddleware returns a middleware which makes the endpoints created by NewGinEndpoint down the
+// chain validate the requests using v once bound. Reasons of the field errors are translated using trans, if not nil.
+// The validator of gin binding is configured by validatorfx.ConfigureValidator with trans, so that the errors
+// of `binding` and `validate` tags are keyed and translated alike.
+// FxRequestValidationModule uses it on the router with the validator of validatorfx.FxModule.
+//
+// e.g. router.Use(NewRequestValidationMiddleware(v, trans))
+func NewRequestValidationMiddleware(v *validator.Validate, trans ut.Translator) gin.HandlerFunc {
+	rv := &requestValidator{
+		validate:     v,
+		trans:        trans,
+		bindingTrans: getBindingTranslator(trans),
+	}
+
+	return func(c *gin.Context) {
+		c.Set(requestValidatorKey, rv)
+		c.Next()
+	}
+}
+
+// getRequestValidator returns the validator set by NewRequestValidationMiddleware, nil if none
+func getRequestValidator(c *gin.Context) *requestValidator {
+	if v, ok := c.Get(requestValidatorKey); ok {
+		return v.(*requestValidator)
+	}
+
+	return nil
+}
+
+// getBindingTranslator configures the validator of gin binding with trans once, nil if it can't be configured
+func getBindingTranslator(trans ut.Translator) ut.Translator {
+	if trans == nil {
+		return nil
+	}
+
+	bindingTranslatorsMu.Lock()
+	defer bindingTranslatorsMu.Unlock()
+	if bindingTrans, ok := bindingTranslators[trans]; ok {
+		return bindingTrans
+	}
+
+	engine, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return nil
+	}
+
+	bindingTrans, err := validatorfx.ConfigureValidator(engine, trans)
+	if err != nil {
+		return nil
+	}
+
+	bindingTranslators[trans] = bindingTrans
+	return bindingTrans
+}
+
+// getRequestBindingTranslator returns the translator of the errors of gin binding, nil if no validator is set
+func getRequestBindingTranslator(c *gin.Context) ut.Translator {
+	if rv := getRequestValidator(c); rv != nil {
+		return rv.bindingTrans
+	}
+
+	return nil
+}
+
+// validateRequest validates the request using the validator, non struct requests are not validated
+func (v *requestValidator) validateRequest(ctx context.Context, request any) error {
+	err := v.validate.StructCtx(ctx, request)
+	invalidValidationErr := &validator.InvalidValidationError{}
+	if err == nil || errors.As(err, &invalidValidationErr) {
+		return nil
+	}
+
+	return newValidationErrorResponse(err, v.trans)
+}
+
+// newValidationErrorResponse creates an errors.ErrInvalidArgumentStr response, field errors are
+// set in the ErrorDetails as field→reason pairs
+func newValidationErrorResponse(err error, trans ut.Translator) errors.ErrorResponse {
+	errResp := errors.NewErrorResponseWithDebug("Invalid Argument", err.Error(), errors.ErrInvalidArgumentStr)
+	if details, ok := validatorfx.GetFieldErrors(err, trans); ok {
+		errResp.ErrorDetails = details
+	}
+
+	return errResp
+}
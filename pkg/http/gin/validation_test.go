@@ -0,0 +1,53 @@
+package ginhttp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/nitesh237/go-server-template/pkg/validatorfx"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRequestValidationMiddleware shares one validator across the tests, so that the validator of gin binding
+// is configured once by the first test, which must not be parallel
+var newTestRequestValidationMiddleware = sync.OnceValue(func() gin.HandlerFunc {
+	v, trans, err := validatorfx.NewValidator()
+	if err != nil {
+		panic(err)
+	}
+	return NewRequestValidationMiddleware(v, trans)
+})
+
+func TestRequestValidationMiddleware(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ep := NewGinEndpoint(func(ctx context.Context, r *greetRequest) (*greetResponse, error) {
+		return &greetResponse{Message: "hello " + r.Name}, nil
+	})
+	router.POST("/unvalidated", ep)
+	validated := router.Group("/", newTestRequestValidationMiddleware())
+	validated.POST("/validated", ep)
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", ContentTypeJSON)
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	w := serve("/validated")
+	a.Equal(http.StatusBadRequest, w.Code)
+	a.Contains(w.Body.String(), `"name"`)
+
+	// routes without the middleware are not validated
+	a.Equal(http.StatusOK, serve("/unvalidated").Code)
+}
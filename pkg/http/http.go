@@ -20,25 +20,32 @@ const (
 	HTTP_POST = "POST"
 )
 
-// NewHttpClient creates a generic http client from the config
+// NewHttpClient creates a generic http client from the config.
+// If Limits or EndpointLimitsList are configured, the requests are rate limited and bulkheaded at the transport.
 func NewHttpClient(httpConf *cfg.HttpClient) *http.Client {
-	return &http.Client{
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   httpConf.Transport.DialContext.Timeout,
-				KeepAlive: httpConf.Transport.DialContext.KeepAlive,
-			}).DialContext,
-			MaxIdleConns:        httpConf.Transport.MaxIdleConns,
-			IdleConnTimeout:     httpConf.Transport.IdleConnTimeout,
-			TLSHandshakeTimeout: httpConf.Transport.TLSHandshakeTimeout,
-			MaxIdleConnsPerHost: httpConf.Transport.MaxIdleConnsPerHost,
-			MaxConnsPerHost:     httpConf.Transport.MaxConnsPerHost,
-			TLSClientConfig: &tls.Config{
-				MinVersion:         tls.VersionTLS12,
-				InsecureSkipVerify: httpConf.Transport.InsecureSkipVerify,
-			},
+	var transport http.RoundTripper = &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   httpConf.Transport.DialContext.Timeout,
+			KeepAlive: httpConf.Transport.DialContext.KeepAlive,
+		}).DialContext,
+		MaxIdleConns:        httpConf.Transport.MaxIdleConns,
+		IdleConnTimeout:     httpConf.Transport.IdleConnTimeout,
+		TLSHandshakeTimeout: httpConf.Transport.TLSHandshakeTimeout,
+		MaxIdleConnsPerHost: httpConf.Transport.MaxIdleConnsPerHost,
+		MaxConnsPerHost:     httpConf.Transport.MaxConnsPerHost,
+		TLSClientConfig: &tls.Config{
+			MinVersion:         tls.VersionTLS12,
+			InsecureSkipVerify: httpConf.Transport.InsecureSkipVerify,
 		},
-		Timeout: httpConf.Timeout,
+	}
+
+	if httpConf.Limits != nil || len(httpConf.EndpointLimitsList) > 0 {
+		transport = newLimitedTransport(transport, httpConf.Limits, httpConf.EndpointLimitsList)
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   httpConf.Timeout,
 	}
 }
 
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"context"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"golang.org/x/time/rate"
+)
+
+// Limiter guards an outbound call. Acquire either blocks or fails as per the configured mode
+// and returns a release func which must be called once the call is complete.
+type Limiter interface {
+	Acquire(ctx context.Context) (release func(), err error)
+}
+
+// NewLimiter creates a Limiter from the config which applies the rate limit followed by the bulkhead.
+// A nil config results in a limiter that never blocks.
+func NewLimiter(conf *cfg.Limits) Limiter {
+	var limiters chainedLimiter
+	if conf == nil {
+		return limiters
+	}
+
+	if conf.RateLimit != nil {
+		limiters = append(limiters, newRateLimiter(conf.RateLimit))
+	}
+
+	if conf.Bulkhead != nil && conf.Bulkhead.MaxConcurrentRequests > 0 {
+		limiters = append(limiters, newBulkhead(conf.Bulkhead))
+	}
+
+	return limiters
+}
+
+func noopRelease() {}
+
+// chainedLimiter acquires all the limiters in order and releases the acquired ones if any of them fails
+type chainedLimiter []Limiter
+
+func (c chainedLimiter) Acquire(ctx context.Context) (func(), error) {
+	releases := make([]func(), 0, len(c))
+	releaseAll := func() {
+		for i := len(releases) - 1; i >= 0; i-- {
+			releases[i]()
+		}
+	}
+
+	for _, l := range c {
+		release, err := l.Acquire(ctx)
+		if err != nil {
+			releaseAll()
+			return nil, err
+		}
+		releases = append(releases, release)
+	}
+
+	return releaseAll, nil
+}
+
+type rateLimiter struct {
+	limiter *rate.Limiter
+	mode    cfg.LimitMode
+	maxWait time.Duration
+}
+
+func newRateLimiter(conf *cfg.RateLimit) *rateLimiter {
+	return &rateLimiter{
+		limiter: NewTokenBucket(conf),
+		mode:    conf.Mode,
+		maxWait: conf.MaxWait,
+	}
+}
+
+// NewTokenBucket creates a token bucket from the rate limit config
+func NewTokenBucket(conf *cfg.RateLimit) *rate.Limiter {
+	limit := rate.Inf
+	if conf.RequestsPerSecond > 0 {
+		limit = rate.Limit(conf.RequestsPerSecond)
+	}
+
+	burst := conf.Burst
+	if burst <= 0 {
+		burst = 1
+	}
+
+	return rate.NewLimiter(limit, burst)
+}
+
+func (r *rateLimiter) Acquire(ctx context.Context) (func(), error) {
+	if r.mode == cfg.LimitModeFailFast {
+		if !r.limiter.Allow() {
+			return nil, errors.Wrap(errors.ErrResourceExhausted, "rate limit exceeded")
+		}
+		return noopRelease, nil
+	}
+
+	waitCtx, cancel := withMaxWait(ctx, r.maxWait)
+	defer cancel()
+
+	if err := r.limiter.Wait(waitCtx); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrap(errors.ErrResourceExhausted, "rate limit exceeded: %s", err.Error())
+	}
+
+	return noopRelease, nil
+}
+
+type bulkhead struct {
+	slots   chan struct{}
+	mode    cfg.LimitMode
+	maxWait time.Duration
+}
+
+func newBulkhead(conf *cfg.Bulkhead) *bulkhead {
+	return &bulkhead{
+		slots:   make(chan struct{}, conf.MaxConcurrentRequests),
+		mode:    conf.Mode,
+		maxWait: conf.MaxWait,
+	}
+}
+
+func (b *bulkhead) Acquire(ctx context.Context) (func(), error) {
+	if b.mode == cfg.LimitModeFailFast {
+		select {
+		case b.slots <- struct{}{}:
+			return b.release, nil
+		default:
+			return nil, errors.Wrap(errors.ErrResourceExhausted, "max concurrent requests reached")
+		}
+	}
+
+	waitCtx, cancel := withMaxWait(ctx, b.maxWait)
+	defer cancel()
+
+	select {
+	case b.slots <- struct{}{}:
+		return b.release, nil
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, errors.Wrap(errors.ErrResourceExhausted, "max concurrent requests reached")
+	}
+}
+
+func (b *bulkhead) release() {
+	<-b.slots
+}
+
+func withMaxWait(ctx context.Context, maxWait time.Duration) (context.Context, context.CancelFunc) {
+	if maxWait <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, maxWait)
+}
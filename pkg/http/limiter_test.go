@@ -0,0 +1,62 @@
+package http
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLimiter_RateLimitFailFast(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(&cfg.Limits{RateLimit: &cfg.RateLimit{RequestsPerSecond: 1, Burst: 2, Mode: cfg.LimitModeFailFast}})
+	a := require.New(t)
+	for i := 0; i < 2; i++ {
+		release, err := l.Acquire(context.Background())
+		a.NoError(err, "burst not honoured")
+		release()
+	}
+	_, err := l.Acquire(context.Background())
+	a.True(errors.Is(err, errors.ErrResourceExhausted), "expected resource exhausted")
+}
+
+func TestLimiter_RateLimitWait(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(&cfg.Limits{RateLimit: &cfg.RateLimit{RequestsPerSecond: 0.1, Mode: cfg.LimitModeWait, MaxWait: 10 * time.Millisecond}})
+	a := require.New(t)
+	release, err := l.Acquire(context.Background())
+	a.NoError(err, "first token not granted")
+	release()
+	_, err = l.Acquire(context.Background())
+	a.True(errors.Is(err, errors.ErrResourceExhausted), "expected resource exhausted after max wait")
+}
+
+func TestLimiter_Bulkhead(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(&cfg.Limits{Bulkhead: &cfg.Bulkhead{MaxConcurrentRequests: 1, Mode: cfg.LimitModeFailFast}})
+	a := require.New(t)
+	release, err := l.Acquire(context.Background())
+	a.NoError(err, "slot not granted")
+	_, err = l.Acquire(context.Background())
+	a.True(errors.Is(err, errors.ErrResourceExhausted), "expected resource exhausted")
+	release()
+	release, err = l.Acquire(context.Background())
+	a.NoError(err, "slot not released")
+	release()
+}
+
+func TestLimiter_BulkheadWaitCanceled(t *testing.T) {
+	t.Parallel()
+	l := NewLimiter(&cfg.Limits{Bulkhead: &cfg.Bulkhead{MaxConcurrentRequests: 1}})
+	a := require.New(t)
+	release, err := l.Acquire(context.Background())
+	a.NoError(err, "slot not granted")
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = l.Acquire(ctx)
+	a.ErrorIs(err, context.Canceled, "expected context error")
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"sync"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+)
+
+// limitedTransport applies the client wide limits followed by the endpoint specific limits
+// before handing over the request to the underlying transport.
+// The acquired limits are released once the response body is closed.
+type limitedTransport struct {
+	next             http.RoundTripper
+	clientLimiter    Limiter
+	endpointLimiters map[string]Limiter
+}
+
+func newLimitedTransport(next http.RoundTripper, limits *cfg.Limits, endpointLimitsList cfg.EndpointLimitsList) *limitedTransport {
+	endpointLimiters := map[string]Limiter{}
+	for key, el := range endpointLimitsList.GetEndpointLimitsMap() {
+		endpointLimiters[key] = NewLimiter(el.Limits)
+	}
+
+	return &limitedTransport{
+		next:             next,
+		clientLimiter:    NewLimiter(limits),
+		endpointLimiters: endpointLimiters,
+	}
+}
+
+func (t *limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiters := chainedLimiter{t.clientLimiter}
+	if l := t.getEndpointLimiter(req); l != nil {
+		limiters = append(limiters, l)
+	}
+
+	release, err := limiters.Acquire(req.Context())
+	if err != nil {
+		// RoundTrip must close the body even on errors, e.g. to stop the writer of a piped body
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	resp.Body = &releaseOnCloseBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// getEndpointLimiter looks up the limiter for method and path, falling back to the one defined for all the methods
+func (t *limitedTransport) getEndpointLimiter(req *http.Request) Limiter {
+	if l, ok := t.endpointLimiters[cfg.GetEndpointKey(req.Method, req.URL.Path)]; ok {
+		return l
+	}
+
+	return t.endpointLimiters[cfg.GetEndpointKey("", req.URL.Path)]
+}
+
+type releaseOnCloseBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnCloseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+type closeRecordingBody struct {
+	io.Reader
+	closed bool
+}
+
+func (b *closeRecordingBody) Close() error {
+	b.closed = true
+	return nil
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestLimitedTransport_ClosesBodyOnRejection(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		_ = req.Body.Close()
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	transport := newLimitedTransport(next, &cfg.Limits{
+		Bulkhead: &cfg.Bulkhead{MaxConcurrentRequests: 1, Mode: cfg.LimitModeFailFast},
+	}, nil)
+
+	newRequest := func() (*http.Request, *closeRecordingBody) {
+		body := &closeRecordingBody{Reader: strings.NewReader(`{}`)}
+		req, err := http.NewRequest(http.MethodPost, "http://localhost/users", body)
+		a.NoError(err)
+		return req, body
+	}
+
+	// the slot is held until the response body is closed
+	req, _ := newRequest()
+	resp, err := transport.RoundTrip(req)
+	a.NoError(err)
+
+	req, body := newRequest()
+	_, err = transport.RoundTrip(req)
+	a.ErrorIs(err, errors.ErrResourceExhausted)
+	a.True(body.closed, "body of the rejected request not closed")
+
+	a.NoError(resp.Body.Close())
+	req, _ = newRequest()
+	resp, err = transport.RoundTrip(req)
+	a.NoError(err, "slot not released")
+	a.NoError(resp.Body.Close())
+}
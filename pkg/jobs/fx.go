@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"go.uber.org/fx"
+	"gorm.io/gorm"
+)
+
+var (
+	// FxWorkerPoolModule runs the WorkerPool for the lifetime of the app with the handlers registered through AsJobHandler.
+	// It requires *cfg.Jobs, *gorm.DB and log.Logger.
+	FxWorkerPoolModule = fx.Module("jobs-worker-pool",
+		fx.Provide(NewWorkerPoolProvider),
+		fx.Invoke(func(lc fx.Lifecycle, pool *WorkerPool) {
+			lc.Append(fx.Hook{
+				OnStart: func(context.Context) error {
+					return pool.Start()
+				},
+				OnStop: pool.Stop,
+			})
+		}),
+	)
+)
+
+type WorkerPoolProviderParams struct {
+	fx.In
+
+	DB       *gorm.DB
+	Conf     *cfg.Jobs
+	Logger   log.Logger
+	Handlers []*JobHandler `group:"JobHandlers"`
+}
+
+func NewWorkerPoolProvider(p WorkerPoolProviderParams) *WorkerPool {
+	pool := NewWorkerPool(p.DB, p.Conf, p.Logger)
+	for _, h := range p.Handlers {
+		pool.Register(h.JobType, h.Handler)
+	}
+
+	return pool
+}
+
+// AsJobHandler annotates the constructor of *JobHandler to be registered with the worker pool
+// e.g. fx.Provide(jobs.AsJobHandler(NewSendEmailJobHandler))
+func AsJobHandler(f any) any {
+	return fx.Annotate(f, fx.ResultTags(`group:"JobHandlers"`))
+}
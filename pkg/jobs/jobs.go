@@ -0,0 +1,150 @@
+// Package jobs is a job queue backed by a postgres table along with the worker pool running the jobs.
+// Refer db/<name>/migrations for the schema of jobs table.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+)
+
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "PENDING"
+	JobStatusRunning   JobStatus = "RUNNING"
+	JobStatusSucceeded JobStatus = "SUCCEEDED"
+	// JobStatusDead is the dead-letter state of the jobs which exhausted their attempts or failed with a non-retryable error
+	JobStatusDead JobStatus = "DEAD"
+)
+
+type Job struct {
+	ID      int64 `gorm:"primaryKey"`
+	JobType string
+	Payload json.RawMessage `gorm:"type:jsonb"`
+	Status  JobStatus
+	// jobs with higher priority are run first among the due jobs
+	Priority int
+	// Attempts is the number of runs of the job including the current one
+	Attempts uint
+	// RunAt is the time after which the job is due
+	RunAt time.Time
+	// LockedUntil is the lease of the worker running the job, the job is taken over by another worker after it
+	LockedUntil *time.Time
+	LastError   string
+	CompletedAt *time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (Job) TableName() string {
+	return "jobs"
+}
+
+// UnmarshalPayload decodes the JSON payload of the job into v
+func (j *Job) UnmarshalPayload(v any) error {
+	if err := json.Unmarshal(j.Payload, v); err != nil {
+		return errors.E(errors.ErrPermanentStr, "invalid job payload", err, errors.Details{"job_type": j.JobType})
+	}
+
+	return nil
+}
+
+type EnqueueOption func(job *Job)
+
+// WithRunAt schedules the job to run at the time, default is now
+func WithRunAt(runAt time.Time) EnqueueOption {
+	return func(job *Job) {
+		job.RunAt = runAt
+	}
+}
+
+// WithDelay schedules the job to run after the delay
+func WithDelay(delay time.Duration) EnqueueOption {
+	return func(job *Job) {
+		job.RunAt = time.Now().Add(delay)
+	}
+}
+
+// WithPriority sets the priority of the job, default is 0
+func WithPriority(priority int) EnqueueOption {
+	return func(job *Job) {
+		job.Priority = priority
+	}
+}
+
+type Queue interface {
+	// Enqueue writes the job with the JSON encoded payload in the transaction of ctx, refer storage.TxManager.
+	// The job is visible to the workers once the transaction commits, or right away if ctx has no transaction.
+	Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error)
+	// Requeue moves a dead job back to the queue to be run now with fresh attempts
+	Requeue(ctx context.Context, id int64) error
+	// DeleteSucceeded deletes the jobs succeeded before the time and returns the number of deleted jobs
+	DeleteSucceeded(ctx context.Context, before time.Time) (int64, error)
+}
+
+type pgQueue struct {
+	txManager storage.TxManager
+}
+
+// NewQueue creates a Queue backed by jobs table
+func NewQueue(txManager storage.TxManager) Queue {
+	return &pgQueue{txManager: txManager}
+}
+
+func (q *pgQueue) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOption) (*Job, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal payload of %s job", jobType)
+	}
+
+	job := &Job{
+		JobType: jobType,
+		Payload: b,
+		Status:  JobStatusPending,
+		RunAt:   time.Now(),
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	if err = q.txManager.DB(ctx).Create(job).Error; err != nil {
+		return nil, errors.Wrap(storage.ClassifyError(err), "failed to enqueue %s job", jobType)
+	}
+
+	return job, nil
+}
+
+func (q *pgQueue) Requeue(ctx context.Context, id int64) error {
+	res := q.txManager.DB(ctx).Model(&Job{}).
+		Where("id = ? AND status = ?", id, JobStatusDead).
+		Updates(map[string]any{
+			"status":       JobStatusPending,
+			"attempts":     0,
+			"run_at":       time.Now(),
+			"locked_until": nil,
+		})
+	if res.Error != nil {
+		return errors.Wrap(storage.ClassifyError(res.Error), "failed to requeue job %d", id)
+	}
+	if res.RowsAffected == 0 {
+		return errors.E(errors.ErrRecordNotFoundStr, "dead job not found", errors.Details{"id": strconv.FormatInt(id, 10)})
+	}
+
+	return nil
+}
+
+func (q *pgQueue) DeleteSucceeded(ctx context.Context, before time.Time) (int64, error) {
+	res := q.txManager.DB(ctx).
+		Where("status = ? AND completed_at < ?", JobStatusSucceeded, before).
+		Delete(&Job{})
+	if res.Error != nil {
+		return 0, errors.Wrap(storage.ClassifyError(res.Error), "failed to delete succeeded jobs")
+	}
+
+	return res.RowsAffected, nil
+}
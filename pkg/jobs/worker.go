@@ -0,0 +1,313 @@
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultConcurrency  = 10
+	defaultPollInterval = time.Second
+	defaultTimeout      = 5 * time.Minute
+	// leaseGrace is added to the timeout of the jobs for the lease, to finish the bookkeeping of a timed out run
+	leaseGrace = 30 * time.Second
+)
+
+var defaultRetryParams = &cfg.RetryParams{
+	ExponentialBackOff: &cfg.ExponentialBackOff{
+		BaseInterval: time.Second,
+		MaxInterval:  time.Hour,
+		MaxAttempts:  10,
+	},
+}
+
+// Handler runs a job, the job is retried as per the cfg.RetryParams of its type if it fails.
+// Errors matching errors.ErrPermanent move the job to the dead-letter state without retries, it includes the errors built
+// by errors.E with a type which isn't retryable e.g. errors.E(errors.ErrInternalServerStr, ...), which are likely bugs.
+// Jobs are run at least once, hence the handlers must be idempotent.
+type Handler func(ctx context.Context, job *Job) error
+
+// JobHandler registers the Handler of a job type with the WorkerPool
+type JobHandler struct {
+	JobType string
+	Handler Handler
+}
+
+// WorkerPool claims the due jobs of the registered types using FOR UPDATE SKIP LOCKED and runs them concurrently.
+// Jobs of a worker which crashed are taken over by another worker once their lease expires.
+type WorkerPool struct {
+	db           *gorm.DB
+	logger       log.Logger
+	handlers     map[string]Handler
+	jobParams    map[string]*cfg.JobParams
+	retryParams  *cfg.RetryParams
+	timeout      time.Duration
+	pollInterval time.Duration
+
+	slots    chan struct{}
+	released chan struct{}
+	inFlight sync.WaitGroup
+	// stop stops the polling, cancelJobs cancels the jobs in flight
+	stop       context.CancelFunc
+	cancelJobs context.CancelFunc
+	done       chan struct{}
+}
+
+func NewWorkerPool(db *gorm.DB, conf *cfg.Jobs, logger log.Logger) *WorkerPool {
+	p := &WorkerPool{
+		db:           db,
+		logger:       logger,
+		handlers:     map[string]Handler{},
+		jobParams:    map[string]*cfg.JobParams{},
+		retryParams:  defaultRetryParams,
+		timeout:      defaultTimeout,
+		pollInterval: defaultPollInterval,
+		released:     make(chan struct{}, 1),
+	}
+
+	concurrency := defaultConcurrency
+	if conf != nil {
+		if conf.Concurrency > 0 {
+			concurrency = conf.Concurrency
+		}
+		if conf.PollInterval > 0 {
+			p.pollInterval = conf.PollInterval
+		}
+		if conf.RetryParams != nil {
+			p.retryParams = conf.RetryParams
+		}
+		if conf.Timeout > 0 {
+			p.timeout = conf.Timeout
+		}
+		p.jobParams = conf.JobParamsList.GetJobParamsMap()
+	}
+	p.slots = make(chan struct{}, concurrency)
+
+	return p
+}
+
+// Register registers the handler of the job type, it must be called before Start
+func (p *WorkerPool) Register(jobType string, handler Handler) {
+	p.handlers[jobType] = handler
+}
+
+// Start starts polling for the jobs of the registered types
+func (p *WorkerPool) Start() error {
+	if len(p.handlers) == 0 {
+		return errors.Wrap(errors.ErrFailedPrecondition, "no job handler registered")
+	}
+
+	pollCtx, stop := context.WithCancel(context.Background())
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	p.stop, p.cancelJobs = stop, cancelJobs
+	p.done = make(chan struct{})
+
+	go p.poll(pollCtx, jobsCtx)
+	return nil
+}
+
+// Stop stops claiming new jobs and waits for the jobs in flight to finish.
+// If ctx is done before, the jobs in flight are canceled and released to be run again without charging the attempt.
+// A job whose release fails is retried once its lease expires.
+func (p *WorkerPool) Stop(ctx context.Context) error {
+	if p.stop == nil {
+		return nil
+	}
+
+	p.stop()
+	<-p.done
+
+	finished := make(chan struct{})
+	go func() {
+		p.inFlight.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+		p.cancelJobs()
+		return nil
+	case <-ctx.Done():
+		p.cancelJobs()
+		return errors.Wrap(ctx.Err(), "jobs in flight canceled")
+	}
+}
+
+func (p *WorkerPool) poll(ctx, jobsCtx context.Context) {
+	defer close(p.done)
+
+	for {
+		free := cap(p.slots) - len(p.slots)
+		if free == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.released:
+				continue
+			}
+		}
+
+		jobs, err := p.claim(ctx, free)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error(ctx, "failed to claim jobs", zap.Error(err))
+		}
+
+		for _, job := range jobs {
+			p.slots <- struct{}{}
+			p.inFlight.Add(1)
+			go func(job *Job) {
+				defer func() {
+					<-p.slots
+					p.inFlight.Done()
+					select {
+					case p.released <- struct{}{}:
+					default:
+					}
+				}()
+				p.execute(jobsCtx, job)
+			}(job)
+		}
+
+		if err == nil && len(jobs) == free {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.pollInterval):
+		}
+	}
+}
+
+// claim leases up to n due jobs in the order of their priority and run at time, along with the jobs whose lease expired
+func (p *WorkerPool) claim(ctx context.Context, n int) ([]*Job, error) {
+	jobTypes := make([]string, 0, len(p.handlers))
+	lease := p.timeout
+	for jobType := range p.handlers {
+		jobTypes = append(jobTypes, jobType)
+		lease = max(lease, p.getTimeout(jobType))
+	}
+
+	now := time.Now()
+	var jobs []*Job
+	err := p.db.WithContext(ctx).Raw(`UPDATE jobs SET status = ?, attempts = attempts + 1, locked_until = ?, updated_at = ?
+WHERE id IN (
+    SELECT id FROM jobs
+    WHERE job_type IN ? AND ((status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?))
+    ORDER BY priority DESC, run_at, id
+    LIMIT ?
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *`,
+		JobStatusRunning, now.Add(lease+leaseGrace), now,
+		jobTypes, JobStatusPending, now, JobStatusRunning, now,
+		n,
+	).Scan(&jobs).Error
+	if err != nil {
+		return nil, errors.Wrap(storage.ClassifyError(err), "failed to claim jobs")
+	}
+
+	return jobs, nil
+}
+
+func (p *WorkerPool) execute(ctx context.Context, job *Job) {
+	runCtx, cancel := context.WithTimeout(ctx, p.getTimeout(job.JobType))
+	err := p.run(runCtx, job)
+	cancel()
+
+	// the outcome is recorded even if the job is canceled by Stop
+	updateCtx, updateCancel := context.WithTimeout(context.WithoutCancel(ctx), leaseGrace)
+	defer updateCancel()
+
+	res := p.db.WithContext(updateCtx).Model(&Job{}).
+		// the job could have been taken over by another worker if the lease expired
+		Where("id = ? AND status = ? AND attempts = ?", job.ID, JobStatusRunning, job.Attempts).
+		Updates(p.outcome(ctx, job, err))
+	if res.Error != nil {
+		p.logger.Error(ctx, "failed to record the outcome of job", zap.Int64("id", job.ID), zap.Error(res.Error))
+	}
+}
+
+// run runs the handler of the job, recovering from its panic
+func (p *WorkerPool) run(ctx context.Context, job *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			p.logger.Error(ctx, "job panic", zap.Int64("id", job.ID), zap.Any("panic", r), zap.ByteString("stack", debug.Stack()))
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return p.handlers[job.JobType](ctx, job)
+}
+
+// outcome returns the updates of the row of the job as per the error of its run, ctx is the context of the jobs
+// which is canceled by Stop
+func (p *WorkerPool) outcome(ctx context.Context, job *Job, err error) map[string]any {
+	now := time.Now()
+	if err == nil {
+		return map[string]any{
+			"status":       JobStatusSucceeded,
+			"locked_until": nil,
+			"completed_at": now,
+			"last_error":   "",
+		}
+	}
+
+	// the job is released without charging the attempt, it's not a failure of the job
+	if ctx.Err() != nil {
+		p.logger.Info(ctx, "job canceled by stop, released", zap.Int64("id", job.ID), zap.String("job_type", job.JobType))
+		return map[string]any{
+			"status":       JobStatusPending,
+			"attempts":     gorm.Expr("attempts - 1"),
+			"locked_until": nil,
+			"run_at":       now,
+		}
+	}
+
+	updates := map[string]any{
+		"locked_until": nil,
+		"last_error":   err.Error(),
+	}
+
+	retryParams := p.getRetryParams(job.JobType)
+	// attempts include the first run which is not a retry
+	if errors.Is(err, errors.ErrPermanent) || job.Attempts > retryParams.GetMaxAttempts() {
+		p.logger.Error(ctx, "job moved to dead-letter", zap.Int64("id", job.ID), zap.String("job_type", job.JobType),
+			zap.Uint("attempts", job.Attempts), zap.Error(err))
+		updates["status"] = JobStatusDead
+		return updates
+	}
+
+	p.logger.Warn(ctx, "job failed, will be retried", zap.Int64("id", job.ID), zap.String("job_type", job.JobType),
+		zap.Uint("attempts", job.Attempts), zap.Error(err))
+	updates["status"] = JobStatusPending
+	updates["run_at"] = now.Add(retryParams.GetBackoff(job.Attempts))
+	return updates
+}
+
+func (p *WorkerPool) getTimeout(jobType string) time.Duration {
+	if jp, ok := p.jobParams[jobType]; ok && jp.Timeout > 0 {
+		return jp.Timeout
+	}
+
+	return p.timeout
+}
+
+func (p *WorkerPool) getRetryParams(jobType string) *cfg.RetryParams {
+	if jp, ok := p.jobParams[jobType]; ok && jp.RetryParams != nil {
+		return jp.RetryParams
+	}
+
+	return p.retryParams
+}
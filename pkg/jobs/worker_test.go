@@ -0,0 +1,80 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestWorkerPool_Outcome(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	pool := NewWorkerPool(nil, &cfg.Jobs{
+		RetryParams: &cfg.RetryParams{RegularInterval: &cfg.RegularInterval{Interval: time.Minute, MaxAttempts: 3}},
+		JobParamsList: cfg.JobParamsList{
+			{JobType: "send_email", RetryParams: &cfg.RetryParams{RegularInterval: &cfg.RegularInterval{Interval: time.Hour, MaxAttempts: 1}}},
+		},
+	}, logger)
+	ctx := context.Background()
+	failure := errors.E(errors.ErrTransientStr, "smtp down")
+
+	updates := pool.outcome(ctx, &Job{ID: 1, JobType: "sync_user", Attempts: 1}, nil)
+	a.Equal(JobStatusSucceeded, updates["status"])
+
+	updates = pool.outcome(ctx, &Job{ID: 1, JobType: "sync_user", Attempts: 3}, failure)
+	a.Equal(JobStatusPending, updates["status"])
+	a.WithinDuration(time.Now().Add(time.Minute), updates["run_at"].(time.Time), time.Second)
+
+	updates = pool.outcome(ctx, &Job{ID: 1, JobType: "sync_user", Attempts: 4}, failure)
+	a.Equal(JobStatusDead, updates["status"])
+
+	// retry params of the job type
+	updates = pool.outcome(ctx, &Job{ID: 2, JobType: "send_email", Attempts: 1}, failure)
+	a.Equal(JobStatusPending, updates["status"])
+	a.WithinDuration(time.Now().Add(time.Hour), updates["run_at"].(time.Time), time.Second)
+
+	updates = pool.outcome(ctx, &Job{ID: 2, JobType: "send_email", Attempts: 2}, failure)
+	a.Equal(JobStatusDead, updates["status"])
+
+	updates = pool.outcome(ctx, &Job{ID: 3, JobType: "sync_user", Attempts: 1}, errors.E(errors.ErrPermanentStr, "invalid"))
+	a.Equal(JobStatusDead, updates["status"])
+
+	// jobs canceled by Stop are released without charging the attempt, even on the last attempt
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	updates = pool.outcome(stopped, &Job{ID: 4, JobType: "send_email", Attempts: 2}, context.Canceled)
+	a.Equal(JobStatusPending, updates["status"])
+	a.Equal(gorm.Expr("attempts - 1"), updates["attempts"])
+	a.Nil(updates["locked_until"])
+
+	// the outcome of a job finished before the cancellation is kept
+	updates = pool.outcome(stopped, &Job{ID: 4, JobType: "send_email", Attempts: 2}, nil)
+	a.Equal(JobStatusSucceeded, updates["status"])
+}
+
+func TestWorkerPool_Run(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	pool := NewWorkerPool(nil, nil, logger)
+	a.ErrorIs(pool.Start(), errors.ErrFailedPrecondition)
+	a.NoError(pool.Stop(context.Background()))
+
+	pool.Register("panics", func(context.Context, *Job) error {
+		panic("boom")
+	})
+	a.ErrorContains(pool.run(context.Background(), &Job{JobType: "panics"}), "panic: boom")
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// SecretResolver resolves the secret of a cfg.SecretSource, caching it for the CacheTtl of the source
+type SecretResolver struct {
+	src *cfg.SecretSource
+
+	mu         sync.Mutex
+	secret     string
+	resolvedAt time.Time
+}
+
+// NewSecretResolver validates src and creates a SecretResolver for it
+func NewSecretResolver(src *cfg.SecretSource) (*SecretResolver, error) {
+	set := 0
+	for _, ok := range []bool{src.Env != "", src.File != "", len(src.Command) != 0} {
+		if ok {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "exactly one of env, file and command must be set in secret source")
+	}
+
+	return &SecretResolver{src: src}, nil
+}
+
+// Resolve returns the cached secret if it's not older than the CacheTtl of the source, reads it from the source otherwise
+func (r *SecretResolver) Resolve(ctx context.Context) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.src.CacheTtl > 0 && !r.resolvedAt.IsZero() && time.Since(r.resolvedAt) < r.src.CacheTtl {
+		return r.secret, nil
+	}
+
+	secret, err := r.read(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	r.secret, r.resolvedAt = secret, time.Now()
+	return secret, nil
+}
+
+func (r *SecretResolver) read(ctx context.Context) (string, error) {
+	switch {
+	case r.src.Env != "":
+		secret, ok := os.LookupEnv(r.src.Env)
+		if !ok {
+			return "", errors.Wrap(errors.ErrFailedPrecondition, "env %s not set", r.src.Env)
+		}
+		return secret, nil
+	case r.src.File != "":
+		b, err := os.ReadFile(r.src.File)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read secret file %s", r.src.File)
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	default:
+		var stderr bytes.Buffer
+		cmd := exec.CommandContext(ctx, r.src.Command[0], r.src.Command[1:]...)
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return "", errors.Wrap(err, "secret command %s failed: %s", r.src.Command[0], stderr.String())
+		}
+		return strings.TrimSpace(string(out)), nil
+	}
+}
+
+// newCredentialsHook returns the pgx BeforeConnect hook setting the credentials resolved from the sources of dsn
+// on every new connection, nil if dsn has no sources
+func newCredentialsHook(dsn *cfg.PgDsn) (func(context.Context, *pgx.ConnConfig) error, error) {
+	var usernameResolver, passwordResolver *SecretResolver
+	var err error
+
+	if dsn.UsernameSource != nil {
+		if usernameResolver, err = NewSecretResolver(dsn.UsernameSource); err != nil {
+			return nil, errors.Wrap(err, "invalid username source")
+		}
+	}
+	if dsn.PasswordSource != nil {
+		if passwordResolver, err = NewSecretResolver(dsn.PasswordSource); err != nil {
+			return nil, errors.Wrap(err, "invalid password source")
+		}
+	}
+
+	if usernameResolver == nil && passwordResolver == nil {
+		return nil, nil
+	}
+
+	return func(ctx context.Context, connConfig *pgx.ConnConfig) error {
+		if usernameResolver != nil {
+			username, err := usernameResolver.Resolve(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve db username")
+			}
+			connConfig.User = username
+		}
+
+		if passwordResolver != nil {
+			password, err := passwordResolver.Resolve(ctx)
+			if err != nil {
+				return errors.Wrap(err, "failed to resolve db password")
+			}
+			connConfig.Password = password
+		}
+
+		return nil
+	}, nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretResolver(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "password")
+	a.NoError(os.WriteFile(file, []byte("secret-1\n"), 0o600))
+
+	fileResolver, err := NewSecretResolver(&cfg.SecretSource{File: file})
+	a.NoError(err)
+	cachedResolver, err := NewSecretResolver(&cfg.SecretSource{File: file, CacheTtl: time.Hour})
+	a.NoError(err)
+
+	for _, r := range []*SecretResolver{fileResolver, cachedResolver} {
+		secret, err := r.Resolve(ctx)
+		a.NoError(err)
+		a.Equal("secret-1", secret)
+	}
+
+	// rotated secret is picked unless cached
+	a.NoError(os.WriteFile(file, []byte("secret-2\n"), 0o600))
+	secret, err := fileResolver.Resolve(ctx)
+	a.NoError(err)
+	a.Equal("secret-2", secret)
+	secret, err = cachedResolver.Resolve(ctx)
+	a.NoError(err)
+	a.Equal("secret-1", secret)
+
+	cmdResolver, err := NewSecretResolver(&cfg.SecretSource{Command: []string{"echo", "token"}})
+	a.NoError(err)
+	secret, err = cmdResolver.Resolve(ctx)
+	a.NoError(err)
+	a.Equal("token", secret)
+
+	_, err = NewSecretResolver(&cfg.SecretSource{Env: "DB_PASSWORD", File: file})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+	_, err = NewSecretResolver(&cfg.SecretSource{})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+}
+
+func TestCredentialsHook(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	hook, err := newCredentialsHook(&cfg.PgDsn{Username: "app", Password: "plain"})
+	a.NoError(err)
+	a.Nil(hook)
+
+	file := filepath.Join(t.TempDir(), "password")
+	a.NoError(os.WriteFile(file, []byte("rotated"), 0o600))
+
+	hook, err = newCredentialsHook(&cfg.PgDsn{Username: "app", PasswordSource: &cfg.SecretSource{File: file}})
+	a.NoError(err)
+
+	connConfig := &pgx.ConnConfig{}
+	connConfig.User = "app"
+	a.NoError(hook(context.Background(), connConfig))
+	a.Equal("app", connConfig.User)
+	a.Equal("rotated", connConfig.Password)
+}
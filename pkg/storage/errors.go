@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// SQLSTATE codes of postgres
+// Refer- https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	PgUniqueViolation           = "23505"
+	PgForeignKeyViolation       = "23503"
+	PgNotNullViolation          = "23502"
+	PgCheckViolation            = "23514"
+	PgExclusionViolation        = "23P01"
+	PgStringDataRightTruncation = "22001"
+	PgNumericValueOutOfRange    = "22003"
+	PgInvalidDatetimeFormat     = "22007"
+	PgDatetimeFieldOverflow     = "22008"
+	PgInvalidTextRepresentation = "22P02"
+	PgSerializationFailure      = "40001"
+	PgDeadlockDetected          = "40P01"
+	PgLockNotAvailable          = "55P03"
+	PgQueryCanceled             = "57014"
+	PgAdminShutdown             = "57P01"
+	PgCannotConnectNow          = "57P03"
+	PgTooManyConnections        = "53300"
+	PgInsufficientPrivilege     = "42501"
+	pgConnectionExceptionClass  = "08"
+)
+
+const (
+	errClassifierPluginName   = "storage:error_classifier"
+	errClassifierCallbackName = "storage:classify_error"
+)
+
+var pgErrorTypes = map[string]errors.ErrorType{
+	PgUniqueViolation:           errors.ErrAlreadyExistsStr,
+	PgExclusionViolation:        errors.ErrAlreadyExistsStr,
+	PgForeignKeyViolation:       errors.ErrFailedPreconditionStr,
+	PgNotNullViolation:          errors.ErrInvalidArgumentStr,
+	PgCheckViolation:            errors.ErrInvalidArgumentStr,
+	PgStringDataRightTruncation: errors.ErrInvalidArgumentStr,
+	PgNumericValueOutOfRange:    errors.ErrInvalidArgumentStr,
+	PgInvalidDatetimeFormat:     errors.ErrInvalidArgumentStr,
+	PgDatetimeFieldOverflow:     errors.ErrInvalidArgumentStr,
+	PgInvalidTextRepresentation: errors.ErrInvalidArgumentStr,
+	PgSerializationFailure:      errors.ErrTransientStr,
+	PgDeadlockDetected:          errors.ErrTransientStr,
+	PgLockNotAvailable:          errors.ErrTransientStr,
+	PgAdminShutdown:             errors.ErrTransientStr,
+	PgCannotConnectNow:          errors.ErrTransientStr,
+	PgTooManyConnections:        errors.ErrTransientStr,
+	PgQueryCanceled:             errors.ErrTimedOutStr,
+	PgInsufficientPrivilege:     errors.ErrPermissionDeniedStr,
+}
+
+// ClassifyError translates *pgconn.PgError into an *errors.Error whose type is derived from the SQLSTATE code e.g.
+// unique_violation is errors.ErrAlreadyExists, serialization_failure is errors.ErrTransient and query_canceled
+// is errors.ErrTimedOut. The sqlstate, constraint, table and column names are set as the details.
+// PgError is kept as the cause and the rest of the errors are returned as is.
+func ClassifyError(err error) error {
+	pgErr := &pgconn.PgError{}
+	if err == nil || !errors.As(err, &pgErr) {
+		return err
+	}
+
+	e := &errors.Error{}
+	if errors.As(err, &e) {
+		// already classified
+		return err
+	}
+
+	errType, ok := pgErrorTypes[pgErr.Code]
+	if !ok && len(pgErr.Code) >= 2 && pgErr.Code[:2] == pgConnectionExceptionClass {
+		errType, ok = errors.ErrTransientStr, true
+	}
+	if !ok {
+		return err
+	}
+
+	details := errors.Details{"sqlstate": pgErr.Code}
+	for k, v := range map[string]string{
+		"constraint": pgErr.ConstraintName,
+		"table":      pgErr.TableName,
+		"column":     pgErr.ColumnName,
+	} {
+		if v != "" {
+			details[k] = v
+		}
+	}
+
+	return errors.E(errType, err, details)
+}
+
+// errorClassifier is a gorm plugin classifying the errors of all the operations using ClassifyError
+type errorClassifier struct{}
+
+func (errorClassifier) Name() string {
+	return errClassifierPluginName
+}
+
+func (errorClassifier) Initialize(db *gorm.DB) error {
+	classify := func(db *gorm.DB) {
+		if db.Error != nil {
+			db.Error = ClassifyError(db.Error)
+		}
+	}
+
+	cb := db.Callback()
+	for _, err := range []error{
+		cb.Create().After("*").Register(errClassifierCallbackName, classify),
+		cb.Query().After("*").Register(errClassifierCallbackName, classify),
+		cb.Update().After("*").Register(errClassifierCallbackName, classify),
+		cb.Delete().After("*").Register(errClassifierCallbackName, classify),
+		cb.Row().After("*").Register(errClassifierCallbackName, classify),
+		cb.Raw().After("*").Register(errClassifierCallbackName, classify),
+	} {
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	for code, sentinel := range map[string]error{
+		PgUniqueViolation:      errors.ErrAlreadyExists,
+		PgForeignKeyViolation:  errors.ErrFailedPrecondition,
+		PgNotNullViolation:     errors.ErrInvalidArgument,
+		PgSerializationFailure: errors.ErrTransient,
+		PgDeadlockDetected:     errors.ErrTransient,
+		PgQueryCanceled:        errors.ErrTimedOut,
+		"08006":                errors.ErrTransient,
+	} {
+		err := ClassifyError(fmt.Errorf("insert: %w", &pgconn.PgError{Code: code}))
+		a.ErrorIs(err, sentinel, code)
+	}
+
+	pgErr := &pgconn.PgError{
+		Code:           PgUniqueViolation,
+		Message:        `duplicate key value violates unique constraint "users_email_key"`,
+		TableName:      "users",
+		ConstraintName: "users_email_key",
+	}
+	err := ClassifyError(pgErr)
+	a.ErrorIs(err, errors.ErrAlreadyExists)
+	a.ErrorAs(err, &pgErr)
+	a.True(errors.IsDuplicateKeyConstraintErr(err))
+	// unclassified postgres errors are left to ClassifyError
+	a.False(errors.IsDuplicateKeyConstraintErr(pgErr))
+	a.False(errors.IsDuplicateKeyConstraintErr(&pgconn.PgError{Code: PgForeignKeyViolation}))
+
+	e := &errors.Error{}
+	a.ErrorAs(err, &e)
+	a.Equal(errors.Details{"sqlstate": PgUniqueViolation, "table": "users", "constraint": "users_email_key"}, e.Details)
+	a.Equal(errors.ErrAlreadyExistsStr, errors.ToErrorResponse(err).ErrorType)
+
+	a.True(errors.E(errors.ErrTransientStr, ClassifyError(&pgconn.PgError{Code: PgDeadlockDetected})).(*errors.Error).Retryable)
+
+	plain := errors.New("boom")
+	a.Equal(plain, ClassifyError(plain))
+	a.Nil(ClassifyError(nil))
+}
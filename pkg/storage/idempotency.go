@@ -0,0 +1,254 @@
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type IdempotencyStatus string
+
+const (
+	IdempotencyStatusInProgress IdempotencyStatus = "IN_PROGRESS"
+	IdempotencyStatusCompleted  IdempotencyStatus = "COMPLETED"
+)
+
+// IdempotencyRecord holds the fingerprint of a request made with an idempotency key and the response sent for it.
+// Refer db/<name>/migrations for the schema of idempotency_keys table.
+type IdempotencyRecord struct {
+	IdempotencyKey     string `gorm:"primaryKey"`
+	RequestFingerprint string
+	// OwnerToken identifies the request which claimed the key, only the owner can complete or release it
+	OwnerToken          string
+	Status              IdempotencyStatus
+	ResponseStatusCode  int
+	ResponseContentType string
+	ResponseHeaders     http.Header `gorm:"serializer:json"`
+	ResponseBody        []byte
+	// in progress record can be taken over by another request after LockedUntil
+	// e.g. when the process handling the request crashed
+	LockedUntil time.Time
+	ExpiresAt   time.Time
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (IdempotencyRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+type IdempotencyStore interface {
+	// Begin claims the key for the request with the fingerprint, the claimed record carries a new OwnerToken.
+	// If the key is already claimed by a live record, the existing record is returned with claimed as false.
+	Begin(ctx context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (record *IdempotencyRecord, claimed bool, err error)
+	// Complete stores the response against a key claimed by the owner.
+	// Returns errors.ErrRecordNotFound if the key is no longer claimed by the owner e.g. taken over after its lock timed out.
+	Complete(ctx context.Context, key, owner string, statusCode int, headers http.Header, body []byte) error
+	// Release removes a key claimed by the owner so that the request can be retried
+	Release(ctx context.Context, key, owner string) error
+	// DeleteExpired removes all the expired records
+	DeleteExpired(ctx context.Context) error
+}
+
+type pgIdempotencyStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresIdempotencyStore creates an IdempotencyStore backed by idempotency_keys table
+func NewPostgresIdempotencyStore(db *gorm.DB) IdempotencyStore {
+	return &pgIdempotencyStore{db: db}
+}
+
+func (s *pgIdempotencyStore) Begin(ctx context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	owner, err := newOwnerToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := pgnow()
+	record := &IdempotencyRecord{
+		IdempotencyKey:     key,
+		RequestFingerprint: fingerprint,
+		OwnerToken:         owner,
+		Status:             IdempotencyStatusInProgress,
+		LockedUntil:        now.Add(lockTimeout),
+		ExpiresAt:          now.Add(ttl),
+	}
+
+	// take over the key only if the existing record has expired or its lock has timed out
+	res := s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "idempotency_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"request_fingerprint", "owner_token", "status", "response_status_code", "response_content_type",
+			"response_headers", "response_body", "locked_until", "expires_at", "created_at", "updated_at",
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Expr{
+				SQL:  "idempotency_keys.expires_at < ? OR (idempotency_keys.status = ? AND idempotency_keys.locked_until < ?)",
+				Vars: []any{now, IdempotencyStatusInProgress, now},
+			},
+		}},
+	}).Create(record)
+	if res.Error != nil {
+		return nil, false, errors.Wrap(res.Error, "failed to claim idempotency key")
+	}
+
+	if res.RowsAffected == 1 {
+		return record, true, nil
+	}
+
+	existing := &IdempotencyRecord{}
+	if err := s.db.WithContext(ctx).Where("idempotency_key = ?", key).Take(existing).Error; err != nil {
+		return nil, false, errors.Wrap(err, "failed to fetch idempotency key")
+	}
+
+	return existing, false, nil
+}
+
+func (s *pgIdempotencyStore) Complete(ctx context.Context, key, owner string, statusCode int, headers http.Header, body []byte) error {
+	headersJSON, err := json.Marshal(headers)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal response headers of idempotency key %s", key)
+	}
+
+	res := s.db.WithContext(ctx).Model(&IdempotencyRecord{}).
+		Where("idempotency_key = ? AND owner_token = ? AND status = ?", key, owner, IdempotencyStatusInProgress).
+		Updates(map[string]any{
+			"status":                IdempotencyStatusCompleted,
+			"response_status_code":  statusCode,
+			"response_content_type": headers.Get("Content-Type"),
+			"response_headers":      string(headersJSON),
+			"response_body":         body,
+		})
+	if res.Error != nil {
+		return errors.Wrap(res.Error, "failed to complete idempotency key")
+	}
+
+	if res.RowsAffected == 0 {
+		return errors.Wrap(errors.ErrRecordNotFound, "no in progress idempotency key %s owned by the request", key)
+	}
+
+	return nil
+}
+
+func (s *pgIdempotencyStore) Release(ctx context.Context, key, owner string) error {
+	err := s.db.WithContext(ctx).
+		Where("idempotency_key = ? AND owner_token = ? AND status = ?", key, owner, IdempotencyStatusInProgress).
+		Delete(&IdempotencyRecord{}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to release idempotency key")
+	}
+
+	return nil
+}
+
+func (s *pgIdempotencyStore) DeleteExpired(ctx context.Context) error {
+	err := s.db.WithContext(ctx).Where("expires_at < ?", pgnow()).Delete(&IdempotencyRecord{}).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to delete expired idempotency keys")
+	}
+
+	return nil
+}
+
+// newOwnerToken returns a random token identifying the request claiming an idempotency key
+func newOwnerToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate owner token of idempotency key")
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+type inMemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+// NewInMemoryIdempotencyStore creates an IdempotencyStore which keeps the records in memory of the process,
+// useful for tests and single instance deployments
+func NewInMemoryIdempotencyStore() IdempotencyStore {
+	return &inMemoryIdempotencyStore{records: map[string]*IdempotencyRecord{}}
+}
+
+func (s *inMemoryIdempotencyStore) Begin(_ context.Context, key, fingerprint string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	owner, err := newOwnerToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if existing, ok := s.records[key]; ok && existing.ExpiresAt.After(now) &&
+		(existing.Status != IdempotencyStatusInProgress || existing.LockedUntil.After(now)) {
+		record := *existing
+		return &record, false, nil
+	}
+
+	record := &IdempotencyRecord{
+		IdempotencyKey:     key,
+		RequestFingerprint: fingerprint,
+		OwnerToken:         owner,
+		Status:             IdempotencyStatusInProgress,
+		LockedUntil:        now.Add(lockTimeout),
+		ExpiresAt:          now.Add(ttl),
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+	s.records[key] = record
+
+	claimed := *record
+	return &claimed, true, nil
+}
+
+func (s *inMemoryIdempotencyStore) Complete(_ context.Context, key, owner string, statusCode int, headers http.Header, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	record, ok := s.records[key]
+	if !ok || record.OwnerToken != owner || record.Status != IdempotencyStatusInProgress {
+		return errors.Wrap(errors.ErrRecordNotFound, "no in progress idempotency key %s owned by the request", key)
+	}
+
+	record.Status = IdempotencyStatusCompleted
+	record.ResponseStatusCode = statusCode
+	record.ResponseContentType = headers.Get("Content-Type")
+	record.ResponseHeaders = headers.Clone()
+	record.ResponseBody = append([]byte(nil), body...)
+	record.UpdatedAt = time.Now()
+	return nil
+}
+
+func (s *inMemoryIdempotencyStore) Release(_ context.Context, key, owner string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if record, ok := s.records[key]; ok && record.OwnerToken == owner && record.Status == IdempotencyStatusInProgress {
+		delete(s.records, key)
+	}
+	return nil
+}
+
+func (s *inMemoryIdempotencyStore) DeleteExpired(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, record := range s.records {
+		if record.ExpiresAt.Before(now) {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
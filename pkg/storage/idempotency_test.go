@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestPostgresIdempotencyStore_Owner(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	pool, err := openPostgresPool(&cfg.PgDsn{Host: "localhost", Port: 5432, SSLMode: DBSSLModeDisable}, &cfg.Storage{GormV2Conf: &cfg.GormV2Conf{}})
+	a.NoError(err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	a.NoError(err)
+	var sql string
+	capture := func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	}
+	a.NoError(db.Callback().Update().After("*").Register("test:capture", capture))
+	a.NoError(db.Callback().Delete().After("*").Register("test:capture", capture))
+	store := NewPostgresIdempotencyStore(db)
+	ctx := context.Background()
+
+	// nothing is updated in dry run, as if the key was taken over by another request
+	err = store.Complete(ctx, "k1", "owner-1", http.StatusCreated, http.Header{"Location": {"/orders/1"}}, []byte("{}"))
+	a.ErrorIs(err, errors.ErrRecordNotFound)
+	a.Contains(sql, `WHERE idempotency_key = 'k1' AND owner_token = 'owner-1' AND status = 'IN_PROGRESS'`)
+	a.Contains(sql, `"response_headers"='{"Location":["/orders/1"]}'`)
+
+	a.NoError(store.Release(ctx, "k1", "owner-1"))
+	a.Contains(sql, `WHERE idempotency_key = 'k1' AND owner_token = 'owner-1' AND status = 'IN_PROGRESS'`)
+}
+
+func TestInMemoryIdempotencyStore(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	store := NewInMemoryIdempotencyStore()
+	ctx := context.Background()
+
+	first, claimed, err := store.Begin(ctx, "k1", "fp", time.Minute, time.Hour)
+	a.NoError(err)
+	a.True(claimed)
+	a.NotEmpty(first.OwnerToken)
+
+	existing, claimed, err := store.Begin(ctx, "k1", "fp", time.Minute, time.Hour)
+	a.NoError(err)
+	a.False(claimed)
+	a.Equal(first.OwnerToken, existing.OwnerToken)
+
+	a.NoError(store.Release(ctx, "k1", "someone-else"))
+	a.ErrorIs(store.Complete(ctx, "k1", "someone-else", http.StatusOK, http.Header{}, nil), errors.ErrRecordNotFound)
+	a.NoError(store.Complete(ctx, "k1", first.OwnerToken, http.StatusOK, http.Header{"Content-Type": {"text/plain"}}, []byte("ok")))
+
+	existing, claimed, err = store.Begin(ctx, "k1", "fp", time.Minute, time.Hour)
+	a.NoError(err)
+	a.False(claimed)
+	a.Equal(IdempotencyStatusCompleted, existing.Status)
+	a.Equal("text/plain", existing.ResponseContentType)
+	a.Equal([]byte("ok"), existing.ResponseBody)
+}
@@ -0,0 +1,166 @@
+package storage
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+)
+
+const defaultLeaderElectionInterval = 5 * time.Second
+
+var (
+	// FxLeaderElectionModule runs the LeaderElector for the lifetime of the app,
+	// it requires *cfg.LeaderElection, *LeaderCallbacks, *gorm.DB and log.Logger.
+	FxLeaderElectionModule = fx.Module("leader-election",
+		fx.Provide(NewLocker, NewLeaderElector),
+		fx.Invoke(runLeaderElector),
+	)
+)
+
+type LeaderCallbacks struct {
+	// OnElected is called when the instance becomes the leader, ctx is canceled once the leadership is revoked.
+	// The lock is held until OnElected returns, so the work of two leaders never overlaps.
+	OnElected func(ctx context.Context)
+	// OnRevoked is called when the instance stops being the leader, including on stop of the elector
+	OnRevoked func()
+}
+
+// LeaderElector elects a single leader among the instances holding the session lock of the election.
+// The leadership is revoked if the session of the lock is lost, after which the instance competes again.
+type LeaderElector struct {
+	tryLock       func(ctx context.Context, name string) (leaderLock, bool, error)
+	name          string
+	callbacks     *LeaderCallbacks
+	logger        log.Logger
+	retryInterval time.Duration
+	checkInterval time.Duration
+	leader        atomic.Bool
+}
+
+// leaderLock is the lock held by the leader, implemented by *SessionLock
+type leaderLock interface {
+	Check(ctx context.Context) error
+	Release(ctx context.Context) error
+}
+
+func NewLeaderElector(locker *Locker, conf *cfg.LeaderElection, callbacks *LeaderCallbacks, logger log.Logger) *LeaderElector {
+	e := &LeaderElector{
+		tryLock: func(ctx context.Context, name string) (leaderLock, bool, error) {
+			return locker.TryLock(ctx, name)
+		},
+		name:          "leader:" + conf.Name,
+		callbacks:     callbacks,
+		logger:        logger,
+		retryInterval: defaultLeaderElectionInterval,
+		checkInterval: defaultLeaderElectionInterval,
+	}
+
+	if conf.RetryInterval > 0 {
+		e.retryInterval = conf.RetryInterval
+	}
+	if conf.CheckInterval > 0 {
+		e.checkInterval = conf.CheckInterval
+	}
+
+	return e
+}
+
+// IsLeader reports whether the instance is the leader currently
+func (e *LeaderElector) IsLeader() bool {
+	return e.leader.Load()
+}
+
+// Run competes for the leadership until ctx is done, releasing the leadership if held
+func (e *LeaderElector) Run(ctx context.Context) {
+	for {
+		lock, acquired, err := e.tryLock(ctx, e.name)
+		if err != nil && ctx.Err() == nil {
+			e.logger.Error(ctx, "failed to compete for leadership", zap.String("election", e.name), zap.Error(err))
+		}
+
+		if acquired {
+			e.lead(ctx, lock)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(e.retryInterval):
+		}
+	}
+}
+
+// lead holds the leadership until ctx is done or the session of the lock is lost
+func (e *LeaderElector) lead(ctx context.Context, lock leaderLock) {
+	leaderCtx, revoke := context.WithCancel(ctx)
+	elected := make(chan struct{})
+	defer func() {
+		e.leader.Store(false)
+		revoke()
+		// the lock is held until the work of the leader is done
+		<-elected
+		if e.callbacks.OnRevoked != nil {
+			e.callbacks.OnRevoked()
+		}
+		e.logger.Info(ctx, "leadership revoked", zap.String("election", e.name))
+
+		if err := lock.Release(context.WithoutCancel(ctx)); err != nil {
+			e.logger.Error(ctx, "failed to release leadership", zap.String("election", e.name), zap.Error(err))
+		}
+	}()
+
+	e.leader.Store(true)
+	e.logger.Info(ctx, "elected as leader", zap.String("election", e.name))
+	go func() {
+		defer close(elected)
+		if e.callbacks.OnElected != nil {
+			e.callbacks.OnElected(leaderCtx)
+		}
+	}()
+
+	ticker := time.NewTicker(e.checkInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			checkCtx, cancel := context.WithTimeout(ctx, e.checkInterval)
+			err := lock.Check(checkCtx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				e.logger.Error(ctx, "lost leadership", zap.String("election", e.name), zap.Error(err))
+				return
+			}
+		}
+	}
+}
+
+func runLeaderElector(lc fx.Lifecycle, elector *LeaderElector) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				elector.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
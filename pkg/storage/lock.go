@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"hash/fnv"
+	"sync"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// interval between the attempts of Lock and TxLock to acquire a held lock
+const lockPollInterval = 100 * time.Millisecond
+
+// AdvisoryLockKey derives the key of the postgres advisory lock from its name
+func AdvisoryLockKey(name string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	return int64(h.Sum64())
+}
+
+// Locker acquires the session scoped postgres advisory locks, each held on a dedicated connection of the pool.
+// The locks are released by postgres if the connection is lost, e.g. the instance holding it crashed.
+type Locker struct {
+	db *gorm.DB
+}
+
+func NewLocker(db *gorm.DB) *Locker {
+	return &Locker{db: db}
+}
+
+// SessionLock is a held session scoped lock
+type SessionLock struct {
+	name string
+	key  int64
+	conn *sql.Conn
+	once sync.Once
+}
+
+// TryLock acquires the lock of name if it's free, returns false if it's held by another session
+func (l *Locker) TryLock(ctx context.Context, name string) (*SessionLock, bool, error) {
+	lock, err := l.newSessionLock(ctx, name)
+	if err != nil {
+		return nil, false, err
+	}
+
+	acquired, err := lock.try(ctx)
+	if err != nil || !acquired {
+		_ = lock.conn.Close()
+		return nil, false, err
+	}
+
+	return lock, true, nil
+}
+
+// Lock waits for the lock of name until it's acquired, ctx is done or the timeout elapses.
+// Failing to acquire the lock in time returns errors.ErrTimedOut. Zero timeout waits until ctx is done.
+func (l *Locker) Lock(ctx context.Context, name string, timeout time.Duration) (*SessionLock, error) {
+	lock, err := l.newSessionLock(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = pollLock(ctx, name, timeout, lock.try); err != nil {
+		_ = lock.conn.Close()
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// WithLock runs fn holding the lock of name, acquired as per Lock and released once fn returns
+func (l *Locker) WithLock(ctx context.Context, name string, timeout time.Duration, fn func(ctx context.Context) error) error {
+	lock, err := l.Lock(ctx, name, timeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Release(context.WithoutCancel(ctx))
+
+	return fn(ctx)
+}
+
+func (l *Locker) newSessionLock(ctx context.Context, name string) (*SessionLock, error) {
+	sqlDB, err := l.db.DB()
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return nil, errors.Wrap(ClassifyError(err), "failed to get connection for lock %s", name)
+	}
+
+	return &SessionLock{name: name, key: AdvisoryLockKey(name), conn: conn}, nil
+}
+
+func (s *SessionLock) try(ctx context.Context) (bool, error) {
+	var acquired bool
+	if err := s.conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", s.key).Scan(&acquired); err != nil {
+		return false, errors.Wrap(ClassifyError(err), "failed to acquire lock %s", s.name)
+	}
+
+	return acquired, nil
+}
+
+// Check verifies that the session holding the lock is alive
+func (s *SessionLock) Check(ctx context.Context) error {
+	if err := s.conn.PingContext(ctx); err != nil {
+		return errors.Wrap(ClassifyError(err), "lost session of lock %s", s.name)
+	}
+
+	return nil
+}
+
+// Release releases the lock and returns its connection to the pool, releasing more than once is a no-op
+func (s *SessionLock) Release(ctx context.Context) error {
+	var err error
+	s.once.Do(func() {
+		if _, execErr := s.conn.ExecContext(ctx, "SELECT pg_advisory_unlock($1)", s.key); execErr != nil {
+			err = errors.Wrap(ClassifyError(execErr), "failed to release lock %s", s.name)
+			// closing the connection ends the session which releases the lock
+			_ = s.conn.Raw(func(any) error { return driver.ErrBadConn })
+		}
+		if closeErr := s.conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	})
+
+	return err
+}
+
+// TryTxLock acquires the transaction scoped lock of name in tx e.g. TxManager.DB(ctx) in TxManager.RunInTx.
+// Returns false if it's held by another transaction, the lock is released when tx ends.
+func TryTxLock(tx *gorm.DB, name string) (bool, error) {
+	if _, ok := tx.Statement.ConnPool.(gorm.TxCommitter); !ok {
+		return false, errors.Wrap(errors.ErrFailedPrecondition, "transaction scoped lock %s must be acquired in a transaction", name)
+	}
+
+	var acquired bool
+	if err := tx.Raw("SELECT pg_try_advisory_xact_lock(?)", AdvisoryLockKey(name)).Scan(&acquired).Error; err != nil {
+		return false, errors.Wrap(ClassifyError(err), "failed to acquire lock %s", name)
+	}
+
+	return acquired, nil
+}
+
+// TxLock waits for the transaction scoped lock of name in tx as per Locker.Lock
+func TxLock(tx *gorm.DB, name string, timeout time.Duration) error {
+	return pollLock(tx.Statement.Context, name, timeout, func(context.Context) (bool, error) {
+		return TryTxLock(tx, name)
+	})
+}
+
+// pollLock calls try until the lock is acquired, ctx is done or the timeout elapses
+func pollLock(ctx context.Context, name string, timeout time.Duration, try func(ctx context.Context) (bool, error)) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	for {
+		acquired, err := try(ctx)
+		if err != nil && ctx.Err() != nil {
+			return errors.Wrap(errors.ErrTimedOut, "failed to acquire lock %s: %s", name, ctx.Err())
+		}
+		if err != nil {
+			return err
+		}
+		if acquired {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(errors.ErrTimedOut, "failed to acquire lock %s: %s", name, ctx.Err())
+		case <-time.After(lockPollInterval):
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestAdvisoryLockKey(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	a.Equal(AdvisoryLockKey("cron:cleanup"), AdvisoryLockKey("cron:cleanup"))
+	a.NotEqual(AdvisoryLockKey("cron:cleanup"), AdvisoryLockKey("cron:report"))
+}
+
+func TestTxLock(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	pool, err := openPostgresPool(&cfg.PgDsn{Host: "localhost", Port: 5432, SSLMode: DBSSLModeDisable}, &cfg.Storage{GormV2Conf: &cfg.GormV2Conf{}})
+	a.NoError(err)
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	a.NoError(err)
+
+	_, err = TryTxLock(db, "cron:cleanup")
+	a.ErrorIs(err, errors.ErrFailedPrecondition)
+
+	attempts := 0
+	start := time.Now()
+	err = pollLock(context.Background(), "cron:cleanup", 250*time.Millisecond, func(context.Context) (bool, error) {
+		attempts++
+		return false, nil
+	})
+	a.ErrorIs(err, errors.ErrTimedOut)
+	a.GreaterOrEqual(time.Since(start), 250*time.Millisecond)
+	a.GreaterOrEqual(attempts, 2)
+}
+
+func TestLocker(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+
+	server := newFakeLockServer()
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(server.connector())}), &gorm.Config{DisableAutomaticPing: true})
+	a.NoError(err)
+	locker := NewLocker(db)
+
+	lock, acquired, err := locker.TryLock(ctx, "cron:cleanup")
+	a.NoError(err)
+	a.True(acquired)
+	a.NoError(lock.Check(ctx))
+
+	_, acquired, err = locker.TryLock(ctx, "cron:cleanup")
+	a.NoError(err)
+	a.False(acquired)
+
+	_, err = locker.Lock(ctx, "cron:cleanup", 150*time.Millisecond)
+	a.ErrorIs(err, errors.ErrTimedOut)
+
+	a.NoError(lock.Release(ctx))
+	a.NoError(lock.Release(ctx))
+
+	ran := false
+	a.NoError(locker.WithLock(ctx, "cron:cleanup", time.Second, func(context.Context) error {
+		ran = true
+		a.True(server.isHeld(AdvisoryLockKey("cron:cleanup")))
+		return nil
+	}))
+	a.True(ran)
+	a.False(server.isHeld(AdvisoryLockKey("cron:cleanup")))
+
+	// the connection is discarded if the unlock fails, which ends the session holding the lock
+	lock, acquired, err = locker.TryLock(ctx, "cron:report")
+	a.NoError(err)
+	a.True(acquired)
+	server.setUnlockErr(io.ErrUnexpectedEOF)
+	a.Error(lock.Release(ctx))
+	a.False(server.isHeld(AdvisoryLockKey("cron:report")))
+}
+
+func TestLeaderElector(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	var mu sync.Mutex
+	var events []string
+	record := func(event string) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, event)
+	}
+
+	lock := &fakeLeaderLock{record: record, lost: make(chan struct{})}
+	elector := NewLeaderElector(nil, &cfg.LeaderElection{
+		Name:          "cron",
+		RetryInterval: time.Hour,
+		CheckInterval: 10 * time.Millisecond,
+	}, &LeaderCallbacks{
+		OnElected: func(ctx context.Context) {
+			record("elected")
+			<-ctx.Done()
+			// the work of the leader is still running after the revoke
+			time.Sleep(50 * time.Millisecond)
+			record("work done")
+		},
+		OnRevoked: func() { record("revoked") },
+	}, logger)
+	elector.tryLock = func(context.Context, string) (leaderLock, bool, error) {
+		return lock, true, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx)
+	}()
+
+	a.Eventually(elector.IsLeader, time.Second, 5*time.Millisecond)
+	close(lock.lost)
+	a.Eventually(func() bool { return !elector.IsLeader() }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-done
+	mu.Lock()
+	defer mu.Unlock()
+	a.Equal([]string{"elected", "work done", "revoked", "released"}, events)
+}
+
+func TestLeaderElector_Stop(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	var released, attempts int
+	lock := &fakeLeaderLock{record: func(string) { released++ }, lost: make(chan struct{})}
+	elector := NewLeaderElector(nil, &cfg.LeaderElection{Name: "cron", RetryInterval: 10 * time.Millisecond}, &LeaderCallbacks{}, logger)
+	elector.tryLock = func(context.Context, string) (leaderLock, bool, error) {
+		attempts++
+		// another instance is the leader at first
+		return lock, attempts > 2, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		elector.Run(ctx)
+	}()
+
+	a.Eventually(elector.IsLeader, time.Second, 5*time.Millisecond)
+	cancel()
+	<-done
+	a.False(elector.IsLeader())
+	a.Equal(3, attempts)
+	a.Equal(1, released)
+}
+
+type fakeLeaderLock struct {
+	record func(event string)
+	lost   chan struct{}
+}
+
+func (l *fakeLeaderLock) Check(context.Context) error {
+	select {
+	case <-l.lost:
+		return io.ErrUnexpectedEOF
+	default:
+		return nil
+	}
+}
+
+func (l *fakeLeaderLock) Release(context.Context) error {
+	l.record("released")
+	return nil
+}
+
+// fakeLockServer emulates the session scoped advisory locks of postgres through a storagetest.Connector
+type fakeLockServer struct {
+	mu        sync.Mutex
+	held      map[int64]*storagetest.Conn
+	unlockErr error
+}
+
+func newFakeLockServer() *fakeLockServer {
+	return &fakeLockServer{held: map[int64]*storagetest.Conn{}}
+}
+
+func (s *fakeLockServer) connector() *storagetest.Connector {
+	return &storagetest.Connector{
+		OnQuery: func(_ context.Context, conn *storagetest.Conn, query string, args []driver.NamedValue) (driver.Rows, error) {
+			if !strings.Contains(query, "pg_try_advisory_lock") {
+				return storagetest.NewRows(nil), nil
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			key := args[0].Value.(int64)
+			owner, ok := s.held[key]
+			if !ok {
+				s.held[key] = conn
+			}
+			return storagetest.NewRows([]string{"acquired"}, []driver.Value{!ok || owner == conn}), nil
+		},
+		OnExec: func(_ context.Context, _ *storagetest.Conn, query string, args []driver.NamedValue) (driver.Result, error) {
+			if !strings.Contains(query, "pg_advisory_unlock") {
+				return driver.RowsAffected(0), nil
+			}
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if s.unlockErr != nil {
+				return nil, s.unlockErr
+			}
+			delete(s.held, args[0].Value.(int64))
+			return driver.RowsAffected(1), nil
+		},
+		// the end of the session releases the locks it holds
+		OnClose: func(conn *storagetest.Conn) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			for key, owner := range s.held {
+				if owner == conn {
+					delete(s.held, key)
+				}
+			}
+		},
+	}
+}
+
+func (s *fakeLockServer) isHeld(key int64) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.held[key]
+	return ok
+}
+
+func (s *fakeLockServer) setUnlockErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.unlockErr = err
+}
@@ -0,0 +1,41 @@
+package migrate
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"go.uber.org/fx"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// FxMigrateOnStartModule applies the pending migrations of the dir of fsys on start of the app,
+// e.g. FxMigrateOnStartModule(db.Migrations, "sample_db/migrations").
+// Migrations are bound by the start timeout of the app, see fx.StartTimeout.
+func FxMigrateOnStartModule(fsys fs.FS, dir string) fx.Option {
+	return fx.Module("migrate-on-start",
+		fx.Invoke(func(lc fx.Lifecycle, db *gorm.DB, logger log.Logger) error {
+			migrator, err := NewMigrator(db, fsys, dir)
+			if err != nil {
+				return err
+			}
+
+			lc.Append(fx.Hook{
+				OnStart: func(ctx context.Context) error {
+					if err := migrator.Up(ctx, 0); err != nil {
+						return err
+					}
+
+					version, _, err := migrator.Version(ctx)
+					if err != nil {
+						return err
+					}
+					logger.InfoNoCtx("Migrations applied", zap.String("dir", dir), zap.Uint64("version", version))
+					return nil
+				},
+			})
+			return nil
+		}),
+	)
+}
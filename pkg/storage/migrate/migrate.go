@@ -0,0 +1,268 @@
+// Package migrate applies the sql migrations of db/<name>/migrations, named as <version>_<title>.<up|down>.sql.
+// The version is recorded in schema_migrations table, compatible with the migrate CLI.
+package migrate
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"gorm.io/gorm"
+)
+
+const (
+	DefaultTable = "schema_migrations"
+)
+
+var migrationFileName = regexp.MustCompile(`^([0-9]+)_(.*)\.(up|down)\.sql$`)
+
+// Migration is a version of the schema
+type Migration struct {
+	Version uint64
+	Title   string
+	Up      string
+	Down    string
+}
+
+// LoadMigrations reads the migrations from the dir of fsys sorted by version
+func LoadMigrations(fsys fs.FS, dir string) ([]*Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read migrations dir %s", dir)
+	}
+
+	migrations := map[uint64]*Migration{}
+	for _, entry := range entries {
+		match := migrationFileName.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, err := strconv.ParseUint(match[1], 10, 64)
+		if err != nil {
+			return nil, errors.Wrap(errors.ErrInvalidArgument, "invalid version of migration %s", entry.Name())
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read migration %s", entry.Name())
+		}
+
+		m, ok := migrations[version]
+		if !ok {
+			m = &Migration{Version: version, Title: match[2]}
+			migrations[version] = m
+		}
+
+		switch match[3] {
+		case "up":
+			m.Up = string(b)
+		case "down":
+			m.Down = string(b)
+		}
+	}
+
+	sorted := make([]*Migration, 0, len(migrations))
+	for _, m := range migrations {
+		sorted = append(sorted, m)
+	}
+	slices.SortFunc(sorted, func(a, b *Migration) int {
+		return cmp.Compare(a.Version, b.Version)
+	})
+
+	return sorted, nil
+}
+
+// Migrator applies the migrations holding an advisory lock, so that concurrent instances of the service
+// don't apply them twice. Each migration runs outside a transaction, hence a failed migration leaves the
+// version dirty which needs to be fixed manually and cleared using Force.
+type Migrator struct {
+	db         *gorm.DB
+	migrations []*Migration
+	table      string
+	lockID     int64
+}
+
+// NewMigrator creates a Migrator for the migrations in the dir of fsys e.g. db.Migrations and sample_db/migrations
+func NewMigrator(db *gorm.DB, fsys fs.FS, dir string) (*Migrator, error) {
+	migrations, err := LoadMigrations(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+		table:      DefaultTable,
+		lockID:     storage.AdvisoryLockKey(DefaultTable),
+	}, nil
+}
+
+// Up applies the next n migrations, all the pending migrations if n <= 0
+func (m *Migrator) Up(ctx context.Context, n int) error {
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		version, err := m.getCleanVersion(conn)
+		if err != nil {
+			return err
+		}
+
+		pending := slices.DeleteFunc(slices.Clone(m.migrations), func(mig *Migration) bool {
+			return mig.Version <= version
+		})
+		if n > 0 && n < len(pending) {
+			pending = pending[:n]
+		}
+
+		for _, mig := range pending {
+			if err = m.apply(conn, mig.Version, mig.Up, mig.Version); err != nil {
+				return errors.Wrap(err, "failed to apply migration %d_%s", mig.Version, mig.Title)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Down reverts the last n applied migrations, all the applied migrations if n <= 0
+func (m *Migrator) Down(ctx context.Context, n int) error {
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		version, err := m.getCleanVersion(conn)
+		if err != nil {
+			return err
+		}
+
+		idx := slices.IndexFunc(m.migrations, func(mig *Migration) bool {
+			return mig.Version == version
+		})
+		if version != 0 && idx < 0 {
+			return errors.Wrap(errors.ErrFailedPrecondition, "no migration found for the current version %d", version)
+		}
+
+		for reverted := 0; idx >= 0 && (n <= 0 || reverted < n); idx, reverted = idx-1, reverted+1 {
+			mig := m.migrations[idx]
+			var prevVersion uint64
+			if idx > 0 {
+				prevVersion = m.migrations[idx-1].Version
+			}
+
+			if err = m.apply(conn, mig.Version, mig.Down, prevVersion); err != nil {
+				return errors.Wrap(err, "failed to revert migration %d_%s", mig.Version, mig.Title)
+			}
+		}
+
+		return nil
+	})
+}
+
+// Version returns the current version, 0 if no migration is applied. dirty is true if the last migration failed.
+func (m *Migrator) Version(ctx context.Context) (version uint64, dirty bool, err error) {
+	conn := m.db.WithContext(ctx)
+	if err = m.ensureTable(conn); err != nil {
+		return 0, false, err
+	}
+
+	return m.getVersion(conn)
+}
+
+// Force sets the version without applying the migrations and clears the dirty flag, version 0 clears the version
+func (m *Migrator) Force(ctx context.Context, version uint64) error {
+	return m.withLock(ctx, func(conn *gorm.DB) error {
+		return m.setVersion(conn, version, false)
+	})
+}
+
+// apply runs the sql of the migration marking the version dirty until it succeeds
+func (m *Migrator) apply(conn *gorm.DB, version uint64, sql string, nextVersion uint64) error {
+	if err := m.setVersion(conn, version, true); err != nil {
+		return err
+	}
+
+	if strings.TrimSpace(sql) != "" {
+		if err := conn.Exec(sql).Error; err != nil {
+			return err
+		}
+	}
+
+	return m.setVersion(conn, nextVersion, false)
+}
+
+// withLock runs fn on a single connection holding the advisory lock of the migrator
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *gorm.DB) error) error {
+	return m.db.WithContext(ctx).Connection(func(conn *gorm.DB) error {
+		if err := conn.Exec("SELECT pg_advisory_lock(?)", m.lockID).Error; err != nil {
+			return errors.Wrap(err, "failed to acquire migration lock")
+		}
+		defer conn.Session(&gorm.Session{Context: context.Background()}).Exec("SELECT pg_advisory_unlock(?)", m.lockID)
+
+		if err := m.ensureTable(conn); err != nil {
+			return err
+		}
+
+		return fn(conn)
+	})
+}
+
+func (m *Migrator) ensureTable(conn *gorm.DB) error {
+	err := conn.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s (version bigint NOT NULL PRIMARY KEY, dirty boolean NOT NULL)", m.table)).Error
+	if err != nil {
+		return errors.Wrap(err, "failed to create %s table", m.table)
+	}
+
+	return nil
+}
+
+func (m *Migrator) getVersion(conn *gorm.DB) (uint64, bool, error) {
+	var rows []struct {
+		Version uint64
+		Dirty   bool
+	}
+	if err := conn.Raw(fmt.Sprintf("SELECT version, dirty FROM %s LIMIT 1", m.table)).Scan(&rows).Error; err != nil {
+		return 0, false, errors.Wrap(err, "failed to get migration version")
+	}
+
+	if len(rows) == 0 {
+		return 0, false, nil
+	}
+
+	return rows[0].Version, rows[0].Dirty, nil
+}
+
+func (m *Migrator) getCleanVersion(conn *gorm.DB) (uint64, error) {
+	version, dirty, err := m.getVersion(conn)
+	if err != nil {
+		return 0, err
+	}
+
+	if dirty {
+		return 0, errors.Wrap(errors.ErrFailedPrecondition, "dirty migration version %d, fix it and force the version", version)
+	}
+
+	return version, nil
+}
+
+func (m *Migrator) setVersion(conn *gorm.DB, version uint64, dirty bool) error {
+	return conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM %s", m.table)).Error; err != nil {
+			return errors.Wrap(err, "failed to clear migration version")
+		}
+
+		if version == 0 && !dirty {
+			return nil
+		}
+
+		err := tx.Exec(fmt.Sprintf("INSERT INTO %s (version, dirty) VALUES (?, ?)", m.table), version, dirty).Error
+		if err != nil {
+			return errors.Wrap(err, "failed to set migration version %d", version)
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,246 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/nitesh237/go-server-template/db"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	migrations, err := LoadMigrations(fstest.MapFS{
+		"m/000010_add_index.up.sql":   {Data: []byte("CREATE INDEX i ON t (c);")},
+		"m/000010_add_index.down.sql": {Data: []byte("DROP INDEX i;")},
+		"m/000002_create_t.up.sql":    {Data: []byte("CREATE TABLE t (c INT);")},
+		"m/README.md":                 {Data: []byte("ignored")},
+	}, "m")
+	a.NoError(err)
+	a.Equal([]*Migration{
+		{Version: 2, Title: "create_t", Up: "CREATE TABLE t (c INT);"},
+		{Version: 10, Title: "add_index", Up: "CREATE INDEX i ON t (c);", Down: "DROP INDEX i;"},
+	}, migrations)
+
+	migrations, err = LoadMigrations(db.Migrations, "sample_db/migrations")
+	a.NoError(err)
+	a.NotEmpty(migrations)
+	a.EqualValues(1, migrations[0].Version)
+}
+
+var testMigrations = fstest.MapFS{
+	"m/000001_create_t.up.sql":     {Data: []byte("CREATE TABLE t (c INT);")},
+	"m/000001_create_t.down.sql":   {Data: []byte("DROP TABLE t;")},
+	"m/000002_add_index.up.sql":    {Data: []byte("CREATE INDEX i ON t (c);")},
+	"m/000002_add_index.down.sql":  {Data: []byte("DROP INDEX i;")},
+	"m/000003_add_column.up.sql":   {Data: []byte("ALTER TABLE t ADD COLUMN d INT;")},
+	"m/000003_add_column.down.sql": {Data: []byte("ALTER TABLE t DROP COLUMN d;")},
+}
+
+func newTestMigrator(a *require.Assertions) (*Migrator, *fakeMigrationServer) {
+	server := &fakeMigrationServer{}
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(server.connector())}), &gorm.Config{DisableAutomaticPing: true})
+	a.NoError(err)
+
+	migrator, err := NewMigrator(gormDB, testMigrations, "m")
+	a.NoError(err)
+	return migrator, server
+}
+
+func TestMigrator(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+
+	migrator, server := newTestMigrator(a)
+
+	a.NoError(migrator.Up(ctx, 2))
+	a.Equal([]string{"CREATE TABLE t (c INT);", "CREATE INDEX i ON t (c);"}, server.flush())
+	assertVersion(a, migrator, 2, false)
+
+	a.NoError(migrator.Up(ctx, 0))
+	a.Equal([]string{"ALTER TABLE t ADD COLUMN d INT;"}, server.flush())
+	assertVersion(a, migrator, 3, false)
+
+	a.NoError(migrator.Down(ctx, 2))
+	a.Equal([]string{"ALTER TABLE t DROP COLUMN d;", "DROP INDEX i;"}, server.flush())
+	assertVersion(a, migrator, 1, false)
+
+	a.NoError(migrator.Down(ctx, 0))
+	a.Equal([]string{"DROP TABLE t;"}, server.flush())
+	assertVersion(a, migrator, 0, false)
+	a.False(server.isLocked())
+}
+
+func TestMigrator_Dirty(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+
+	migrator, server := newTestMigrator(a)
+	server.setFailingStatement("CREATE INDEX i ON t (c);")
+
+	// the failed migration leaves its version dirty
+	a.ErrorIs(migrator.Up(ctx, 0), io.ErrUnexpectedEOF)
+	a.Equal([]string{"CREATE TABLE t (c INT);", "CREATE INDEX i ON t (c);"}, server.flush())
+	assertVersion(a, migrator, 2, true)
+
+	// dirty version is refused until forced
+	server.setFailingStatement("")
+	a.ErrorIs(migrator.Up(ctx, 0), errors.ErrFailedPrecondition)
+	a.ErrorIs(migrator.Down(ctx, 0), errors.ErrFailedPrecondition)
+	a.Empty(server.flush())
+
+	a.NoError(migrator.Force(ctx, 1))
+	assertVersion(a, migrator, 1, false)
+	a.Empty(server.flush())
+
+	a.NoError(migrator.Up(ctx, 0))
+	a.Equal([]string{"CREATE INDEX i ON t (c);", "ALTER TABLE t ADD COLUMN d INT;"}, server.flush())
+	assertVersion(a, migrator, 3, false)
+
+	a.NoError(migrator.Force(ctx, 0))
+	assertVersion(a, migrator, 0, false)
+}
+
+func TestMigrator_LockContention(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	migrator, server := newTestMigrator(a)
+	// another instance is applying the migrations
+	other := &storagetest.Conn{}
+	server.lock(context.Background(), other)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	a.ErrorIs(migrator.Up(ctx, 0), context.DeadlineExceeded)
+	a.ErrorIs(migrator.Force(ctx, 1), context.DeadlineExceeded)
+	a.Empty(server.flush())
+	assertVersion(a, migrator, 0, false)
+
+	// waits for the other instance to release the lock
+	time.AfterFunc(50*time.Millisecond, func() { server.unlock(other) })
+	a.NoError(migrator.Up(context.Background(), 0))
+	a.Len(server.flush(), 3)
+	a.False(server.isLocked())
+}
+
+func assertVersion(a *require.Assertions, migrator *Migrator, expectedVersion uint64, expectedDirty bool) {
+	version, dirty, err := migrator.Version(context.Background())
+	a.NoError(err)
+	a.Equal(expectedVersion, version)
+	a.Equal(expectedDirty, dirty)
+}
+
+// fakeMigrationServer emulates the schema_migrations table and the advisory lock of the migrator
+// through a storagetest.Connector, recording the statements of the migrations
+type fakeMigrationServer struct {
+	mu               sync.Mutex
+	locker           *storagetest.Conn
+	version          *int64
+	dirty            bool
+	statements       []string
+	failingStatement string
+}
+
+func (s *fakeMigrationServer) connector() *storagetest.Connector {
+	return &storagetest.Connector{
+		OnExec: func(ctx context.Context, conn *storagetest.Conn, query string, args []driver.NamedValue) (driver.Result, error) {
+			switch {
+			case strings.Contains(query, "pg_advisory_lock"):
+				return driver.RowsAffected(0), s.lock(ctx, conn)
+			case strings.Contains(query, "pg_advisory_unlock"):
+				s.unlock(conn)
+			case strings.HasPrefix(query, "CREATE TABLE IF NOT EXISTS "+DefaultTable):
+			case strings.HasPrefix(query, "DELETE FROM "+DefaultTable):
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.version, s.dirty = nil, false
+			case strings.HasPrefix(query, "INSERT INTO "+DefaultTable):
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				version := args[0].Value.(int64)
+				s.version, s.dirty = &version, args[1].Value.(bool)
+			default:
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.statements = append(s.statements, query)
+				if query == s.failingStatement {
+					return nil, io.ErrUnexpectedEOF
+				}
+			}
+			return driver.RowsAffected(1), nil
+		},
+		OnQuery: func(_ context.Context, _ *storagetest.Conn, query string, _ []driver.NamedValue) (driver.Rows, error) {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			if !strings.HasPrefix(query, "SELECT version, dirty FROM "+DefaultTable) || s.version == nil {
+				return storagetest.NewRows(nil), nil
+			}
+			return storagetest.NewRows([]string{"version", "dirty"}, []driver.Value{*s.version, s.dirty}), nil
+		},
+		OnClose: s.unlock,
+	}
+}
+
+// lock waits for the advisory lock like pg_advisory_lock until ctx is done
+func (s *fakeMigrationServer) lock(ctx context.Context, conn *storagetest.Conn) error {
+	for {
+		s.mu.Lock()
+		if s.locker == nil || s.locker == conn {
+			s.locker = conn
+			s.mu.Unlock()
+			return nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Millisecond):
+		}
+	}
+}
+
+func (s *fakeMigrationServer) unlock(conn *storagetest.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.locker == conn {
+		s.locker = nil
+	}
+}
+
+func (s *fakeMigrationServer) isLocked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.locker != nil
+}
+
+func (s *fakeMigrationServer) setFailingStatement(statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failingStatement = statement
+}
+
+// flush returns the recorded statements of the migrations and clears them
+func (s *fakeMigrationServer) flush() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statements := s.statements
+	s.statements = nil
+	return statements
+}
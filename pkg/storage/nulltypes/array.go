@@ -0,0 +1,97 @@
+package nulltypes
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"sync"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// pgtype.Map caches the codec plans and is not safe for concurrent use
+var typeMaps = sync.Pool{New: func() any { return pgtype.NewMap() }}
+
+// Array is a postgres array of T e.g. Array[string] for text[] and Array[int64] for bigint[], NULL if valid is false.
+// Arrays with NULL elements must use a pointer element type e.g. Array[*string].
+type Array[T any] struct {
+	Elems []T
+	Valid bool
+}
+
+// Factory method to initialize an Array with valid true, including an empty array
+func NewValidArray[T any](elems ...T) Array[T] {
+	if elems == nil {
+		elems = []T{}
+	}
+	return Array[T]{Elems: elems, Valid: true}
+}
+
+// Method to get value
+// Returns elems if valid is true else returns nil
+func (a Array[T]) GetValue() []T {
+	if a.Valid {
+		return a.Elems
+	}
+	return nil
+}
+
+func (a *Array[T]) Scan(src any) error {
+	if src == nil {
+		*a = Array[T]{}
+		return nil
+	}
+
+	m := typeMaps.Get().(*pgtype.Map)
+	defer typeMaps.Put(m)
+
+	elems := []T{}
+	if err := m.SQLScanner(&elems).Scan(src); err != nil {
+		return errors.Wrap(err, "failed to scan array")
+	}
+	a.Elems, a.Valid = elems, true
+	return nil
+}
+
+func (a Array[T]) Value() (driver.Value, error) {
+	if !a.Valid {
+		return nil, nil
+	}
+
+	m := typeMaps.Get().(*pgtype.Map)
+	defer typeMaps.Put(m)
+
+	elems := a.Elems
+	if elems == nil {
+		elems = []T{}
+	}
+	t, ok := m.TypeForValue(elems)
+	if !ok {
+		return nil, errors.New("no postgres array type for %T", elems)
+	}
+	buf, err := m.Encode(t.OID, pgtype.TextFormatCode, elems, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode array")
+	}
+	return string(buf), nil
+}
+
+func (a Array[T]) MarshalJSON() ([]byte, error) {
+	if a.Valid {
+		return json.Marshal(a.Elems)
+	}
+	return json.Marshal(nil)
+}
+
+func (a *Array[T]) UnmarshalJSON(data []byte) error {
+	var elems *[]T
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return err
+	}
+	if elems != nil {
+		*a = NewValidArray(*elems...)
+	} else {
+		*a = Array[T]{}
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package nulltypes
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+)
 
 type NullBool struct {
 	sql.NullBool
@@ -15,7 +18,7 @@ func (n NullBool) GetValue() bool {
 	return false
 }
 
-// Factory method to initialize a NullBool with valid true
+// Factory method to initialize a NullBool with valid true, false is treated as NULL. Use NewValidNullBool to keep it
 func NewNullBool(val bool) NullBool {
 	if !val {
 		return NullBool{struct {
@@ -28,3 +31,28 @@ func NewNullBool(val bool) NullBool {
 		Valid bool
 	}{Bool: val, Valid: true}}
 }
+
+// Factory method to initialize a NullBool with valid true, including the zero value
+func NewValidNullBool(val bool) NullBool {
+	return NullBool{sql.NullBool{Bool: val, Valid: true}}
+}
+
+func (n NullBool) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.Bool)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *NullBool) UnmarshalJSON(data []byte) error {
+	var v *bool
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.Bool, n.Valid = *v, true
+	} else {
+		n.Bool, n.Valid = false, false
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package nulltypes
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// NullDecimal is an arbitrary precision numeric, a wrapper struct over pgtype.Numeric.
+// It marshals to a JSON number, or null if valid is false.
+type NullDecimal struct {
+	pgtype.Numeric
+}
+
+// Factory method to initialize a NullDecimal with valid true from its text e.g. "12.50"
+func NewValidNullDecimal(val string) (NullDecimal, error) {
+	var n NullDecimal
+	if err := n.Scan(val); err != nil {
+		return NullDecimal{}, errors.Wrap(errors.ErrInvalidArgument, "invalid decimal %q: %s", val, err)
+	}
+	return n, nil
+}
+
+// Method to get value
+// Returns the text of value if valid is true else returns empty string
+func (n NullDecimal) GetValue() string {
+	if !n.Valid {
+		return ""
+	}
+	v, err := n.Value()
+	if err != nil {
+		return ""
+	}
+	return v.(string)
+}
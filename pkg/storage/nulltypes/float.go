@@ -1,6 +1,9 @@
 package nulltypes
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+)
 
 type NullFloat64 struct {
 	sql.NullFloat64
@@ -15,7 +18,7 @@ func (n NullFloat64) GetValue() float64 {
 	return 0
 }
 
-// Factory method to initialize a NullFloat64 with valid true
+// Factory method to initialize a NullFloat64 with valid true, 0 is treated as NULL. Use NewValidNullFloat64 to keep it
 func NewNullFloat64(val float64) NullFloat64 {
 	if val == 0 {
 		return NullFloat64{struct {
@@ -28,3 +31,28 @@ func NewNullFloat64(val float64) NullFloat64 {
 		Valid   bool
 	}{Float64: val, Valid: true}}
 }
+
+// Factory method to initialize a NullFloat64 with valid true, including the zero value
+func NewValidNullFloat64(val float64) NullFloat64 {
+	return NullFloat64{sql.NullFloat64{Float64: val, Valid: true}}
+}
+
+func (n NullFloat64) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.Float64)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *NullFloat64) UnmarshalJSON(data []byte) error {
+	var v *float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.Float64, n.Valid = *v, true
+	} else {
+		n.Float64, n.Valid = 0, false
+	}
+	return nil
+}
@@ -1,6 +1,9 @@
 package nulltypes
 
-import "database/sql"
+import (
+	"database/sql"
+	"encoding/json"
+)
 
 type NullInt64 struct {
 	sql.NullInt64
@@ -15,7 +18,7 @@ func (n NullInt64) GetValue() int64 {
 	return 0
 }
 
-// Factory method to initialize a NullInt64 with valid true
+// Factory method to initialize a NullInt64 with valid true, 0 is treated as NULL. Use NewValidNullInt64 to keep it
 func NewNullInt64(val int64) NullInt64 {
 	if val == 0 {
 		return NullInt64{struct {
@@ -29,6 +32,31 @@ func NewNullInt64(val int64) NullInt64 {
 	}{Int64: val, Valid: true}}
 }
 
+// Factory method to initialize a NullInt64 with valid true, including the zero value
+func NewValidNullInt64(val int64) NullInt64 {
+	return NullInt64{sql.NullInt64{Int64: val, Valid: true}}
+}
+
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.Int64)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *NullInt64) UnmarshalJSON(data []byte) error {
+	var v *int64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.Int64, n.Valid = *v, true
+	} else {
+		n.Int64, n.Valid = 0, false
+	}
+	return nil
+}
+
 type NullInt16 struct {
 	sql.NullInt16
 }
@@ -42,7 +70,7 @@ func (n NullInt16) GetValue() int16 {
 	return 0
 }
 
-// Factory method to initialize a NullInt16 with valid true
+// Factory method to initialize a NullInt16 with valid true, 0 is treated as NULL. Use NewValidNullInt16 to keep it
 func NewNullInt16(val int16) NullInt16 {
 	if val == 0 {
 		return NullInt16{struct {
@@ -55,3 +83,28 @@ func NewNullInt16(val int16) NullInt16 {
 		Valid bool
 	}{Int16: val, Valid: true}}
 }
+
+// Factory method to initialize a NullInt16 with valid true, including the zero value
+func NewValidNullInt16(val int16) NullInt16 {
+	return NullInt16{sql.NullInt16{Int16: val, Valid: true}}
+}
+
+func (n NullInt16) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.Int16)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *NullInt16) UnmarshalJSON(data []byte) error {
+	var v *int16
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.Int16, n.Valid = *v, true
+	} else {
+		n.Int16, n.Valid = 0, false
+	}
+	return nil
+}
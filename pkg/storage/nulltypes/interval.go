@@ -0,0 +1,49 @@
+package nulltypes
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// NullInterval is a postgres interval, a wrapper struct over pgtype.Interval.
+// It marshals to the postgres text of the interval e.g. "1 mon 2 day 03:00:00", or null if valid is false.
+type NullInterval struct {
+	pgtype.Interval
+}
+
+// Factory method to initialize a NullInterval with valid true from a duration
+func NewValidNullInterval(val time.Duration) NullInterval {
+	return NullInterval{pgtype.Interval{Microseconds: val.Microseconds(), Valid: true}}
+}
+
+// Method to get value
+// Returns value as duration if valid is true else returns 0, a day is 24 hours and a month is 30 days
+func (n NullInterval) GetValue() time.Duration {
+	if !n.Valid {
+		return 0
+	}
+	days := int64(n.Months)*30 + int64(n.Days)
+	return time.Duration(n.Microseconds)*time.Microsecond + time.Duration(days)*24*time.Hour
+}
+
+func (n NullInterval) MarshalJSON() ([]byte, error) {
+	v, err := n.Value()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(v)
+}
+
+func (n *NullInterval) UnmarshalJSON(data []byte) error {
+	var v *string
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v == nil {
+		*n = NullInterval{}
+		return nil
+	}
+	return n.Scan(*v)
+}
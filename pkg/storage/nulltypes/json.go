@@ -0,0 +1,90 @@
+package nulltypes
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"encoding/json"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// JSON stores T as a jsonb column, NULL if valid is false
+type JSON[T any] struct {
+	Data  T
+	Valid bool
+}
+
+// Factory method to initialize a JSON with valid true
+func NewValidJSON[T any](data T) JSON[T] {
+	return JSON[T]{Data: data, Valid: true}
+}
+
+// Method to get value
+// Returns data if valid is true else returns the zero value of T
+func (j JSON[T]) GetValue() T {
+	if j.Valid {
+		return j.Data
+	}
+	var zero T
+	return zero
+}
+
+// GormDataType is the column type used by the gorm migrator
+func (JSON[T]) GormDataType() string {
+	return "jsonb"
+}
+
+func (j *JSON[T]) Scan(src any) error {
+	var data []byte
+	switch src := src.(type) {
+	case nil:
+		*j = JSON[T]{}
+		return nil
+	case []byte:
+		data = src
+	case string:
+		data = []byte(src)
+	default:
+		return errors.New("cannot scan %T into JSON", src)
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return errors.Wrap(err, "failed to unmarshal JSON column")
+	}
+	j.Data, j.Valid = v, true
+	return nil
+}
+
+func (j JSON[T]) Value() (driver.Value, error) {
+	if !j.Valid {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(j.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal JSON column")
+	}
+	return string(data), nil
+}
+
+func (j JSON[T]) MarshalJSON() ([]byte, error) {
+	if j.Valid {
+		return json.Marshal(j.Data)
+	}
+	return json.Marshal(nil)
+}
+
+func (j *JSON[T]) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*j = JSON[T]{}
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	j.Data, j.Valid = v, true
+	return nil
+}
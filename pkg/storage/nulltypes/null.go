@@ -0,0 +1,47 @@
+package nulltypes
+
+import (
+	"database/sql"
+	"encoding/json"
+)
+
+// Null is a wrapper struct over sql.Null for the types without a dedicated null type
+type Null[T any] struct {
+	sql.Null[T]
+}
+
+// Method to get value
+// Returns value if valid is true else returns the zero value of T
+func (n Null[T]) GetValue() T {
+	if n.Valid {
+		return n.V
+	}
+	var zero T
+	return zero
+}
+
+// Factory method to initialize a Null with valid true, including the zero value
+func NewValidNull[T any](val T) Null[T] {
+	return Null[T]{sql.Null[T]{V: val, Valid: true}}
+}
+
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.V)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *Null[T]) UnmarshalJSON(data []byte) error {
+	var v *T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.V, n.Valid = *v, true
+	} else {
+		var zero T
+		n.V, n.Valid = zero, false
+	}
+	return nil
+}
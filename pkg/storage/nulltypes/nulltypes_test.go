@@ -0,0 +1,132 @@
+package nulltypes
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidZeroValues(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	a.False(NewNullInt64(0).Valid)
+	a.True(NewValidNullInt64(0).Valid)
+	a.True(NewValidNullString("").Valid)
+	a.True(NewValidNullBool(false).Valid)
+
+	type row struct {
+		Count   NullInt64    `json:"count"`
+		Name    NullString   `json:"name"`
+		Score   Null[uint32] `json:"score"`
+		ID      NullUUID     `json:"id"`
+		Balance NullDecimal  `json:"balance"`
+		Every   NullInterval `json:"every"`
+	}
+	data, err := json.Marshal(row{Count: NewValidNullInt64(0)})
+	a.NoError(err)
+	a.JSONEq(`{"count":0,"name":null,"score":null,"id":null,"balance":null,"every":null}`, string(data))
+
+	var r row
+	a.NoError(json.Unmarshal([]byte(`{"count":null,"name":"","score":0,"balance":12.50,"every":"01:30:00"}`), &r))
+	a.False(r.Count.Valid)
+	// the empty string is unmarshalled as NULL for backward compatibility
+	a.False(r.Name.Valid)
+	a.Equal(NewValidNull[uint32](0), r.Score)
+	a.Equal("12.50", r.Balance.GetValue())
+	a.Equal(90*time.Minute, r.Every.GetValue())
+	a.Equal(uuid.Nil, r.ID.GetValue())
+}
+
+func TestNullString_UnmarshalJSON(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	for data, want := range map[string]NullString{
+		`""`:    {},
+		`null`:  {},
+		`"foo"`: NewNullString("foo"),
+	} {
+		var ns NullString
+		a.NoError(json.Unmarshal([]byte(data), &ns), data)
+		a.Equal(want, ns, data)
+	}
+
+	// the valid empty string doesn't survive the round trip
+	data, err := json.Marshal(NewValidNullString(""))
+	a.NoError(err)
+	a.Equal(`""`, string(data))
+	var ns NullString
+	a.NoError(json.Unmarshal(data, &ns))
+	a.False(ns.Valid)
+}
+
+func TestJSON(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	type prefs struct {
+		Theme string `json:"theme"`
+	}
+	v, err := NewValidJSON(prefs{Theme: "dark"}).Value()
+	a.NoError(err)
+	a.Equal(`{"theme":"dark"}`, v)
+
+	var j JSON[prefs]
+	a.NoError(j.Scan([]byte(`{"theme":"light"}`)))
+	a.Equal(NewValidJSON(prefs{Theme: "light"}), j)
+	a.NoError(j.Scan(nil))
+	a.False(j.Valid)
+	a.Error(j.Scan(42))
+
+	v, err = j.Value()
+	a.NoError(err)
+	a.Nil(v)
+}
+
+func TestArray(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	v, err := NewValidArray("a", `b"c`).Value()
+	a.NoError(err)
+	a.Equal(`{a,"b\"c"}`, v)
+
+	v, err = NewValidArray[int64]().Value()
+	a.NoError(err)
+	a.Equal("{}", v)
+
+	var ints Array[int64]
+	a.NoError(ints.Scan("{1,2,3}"))
+	a.Equal([]int64{1, 2, 3}, ints.GetValue())
+
+	var strs Array[*string]
+	a.NoError(strs.Scan(`{x,NULL}`))
+	a.Len(strs.Elems, 2)
+	a.Nil(strs.Elems[1])
+
+	a.NoError(ints.Scan(nil))
+	a.False(ints.Valid)
+
+	var invalid Array[int64]
+	a.Error(invalid.Scan("{a}"))
+}
+
+func TestNullInterval(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	v, err := NewValidNullInterval(90 * time.Minute).Value()
+	a.NoError(err)
+	a.Equal("01:30:00.000000", v)
+
+	var n NullInterval
+	a.NoError(n.Scan("1 mon 2 day 03:00:00"))
+	a.Equal(32*24*time.Hour+3*time.Hour, n.GetValue())
+
+	_, err = NewValidNullDecimal("not a number")
+	a.Error(err)
+}
@@ -19,7 +19,7 @@ func (ns NullString) GetValue() string {
 	return ""
 }
 
-// Factory method to initialize a NullString with valid true
+// Factory method to initialize a NullString with valid true, the empty string is treated as NULL. Use NewValidNullString to keep it
 func NewNullString(val string) NullString {
 	if val == "" {
 		return NullString{struct {
@@ -33,18 +33,21 @@ func NewNullString(val string) NullString {
 	}{String: val, Valid: true}}
 }
 
+// Factory method to initialize a NullString with valid true, including the empty string
+func NewValidNullString(val string) NullString {
+	return NullString{sql.NullString{String: val, Valid: true}}
+}
+
 // ref - https://gist.github.com/keidrun/d1b2791f840753e25070771b857af7ba
 // ref - https://stackoverflow.com/questions/33072172/how-can-i-work-with-sql-null-values-and-json-in-a-good-way
-func (ns *NullString) MarshalJSON() ([]byte, error) {
-	if ns == nil {
-		return json.Marshal(nil)
-	}
+func (ns NullString) MarshalJSON() ([]byte, error) {
 	if ns.Valid {
 		return json.Marshal(ns.String)
 	}
 	return json.Marshal(nil)
 }
 
+// UnmarshalJSON treats the empty string as NULL like NewNullString
 func (ns *NullString) UnmarshalJSON(data []byte) error {
 	var s *string
 	if err := json.Unmarshal(data, &s); err != nil {
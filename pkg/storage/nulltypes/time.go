@@ -2,6 +2,7 @@ package nulltypes
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -18,7 +19,7 @@ func (ns NullTime) GetValue() time.Time {
 	return time.Time{}
 }
 
-// Factory method to initialize a NullTime with valid true
+// Factory method to initialize a NullTime with valid true, the zero time is treated as NULL. Use NewValidNullTime to keep it
 func NewNullTime(val time.Time) NullTime {
 	empty := time.Time{}
 	if val == empty {
@@ -32,3 +33,28 @@ func NewNullTime(val time.Time) NullTime {
 		Valid bool
 	}{Time: val, Valid: true}}
 }
+
+// Factory method to initialize a NullTime with valid true, including the zero value
+func NewValidNullTime(val time.Time) NullTime {
+	return NullTime{sql.NullTime{Time: val, Valid: true}}
+}
+
+func (n NullTime) MarshalJSON() ([]byte, error) {
+	if n.Valid {
+		return json.Marshal(n.Time)
+	}
+	return json.Marshal(nil)
+}
+
+func (n *NullTime) UnmarshalJSON(data []byte) error {
+	var v *time.Time
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	if v != nil {
+		n.Time, n.Valid = *v, true
+	} else {
+		n.Time, n.Valid = time.Time{}, false
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+package nulltypes
+
+import "github.com/google/uuid"
+
+// A wrapper struct over uuid.NullUUID, it marshals to JSON null if valid is false
+type NullUUID struct {
+	uuid.NullUUID
+}
+
+// Method to get value
+// Returns value uuid if valid is true else returns uuid.Nil
+func (n NullUUID) GetValue() uuid.UUID {
+	if n.Valid {
+		return n.UUID
+	}
+	return uuid.Nil
+}
+
+// Factory method to initialize a NullUUID with valid true, including uuid.Nil
+func NewValidNullUUID(val uuid.UUID) NullUUID {
+	return NullUUID{uuid.NullUUID{UUID: val, Valid: true}}
+}
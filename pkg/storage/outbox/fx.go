@@ -0,0 +1,39 @@
+package outbox
+
+import (
+	"context"
+
+	"go.uber.org/fx"
+)
+
+var (
+	// FxRelayModule runs the Relay for the lifetime of the app, it requires a Publisher, *cfg.Outbox, *gorm.DB and log.Logger.
+	FxRelayModule = fx.Module("outbox-relay",
+		fx.Provide(NewRelay),
+		fx.Invoke(runRelay),
+	)
+)
+
+func runRelay(lc fx.Lifecycle, relay *Relay) {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	lc.Append(fx.Hook{
+		OnStart: func(context.Context) error {
+			go func() {
+				defer close(done)
+				relay.Run(ctx)
+			}()
+			return nil
+		},
+		OnStop: func(stopCtx context.Context) error {
+			cancel()
+			select {
+			case <-done:
+				return nil
+			case <-stopCtx.Done():
+				return stopCtx.Err()
+			}
+		},
+	})
+}
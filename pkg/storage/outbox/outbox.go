@@ -0,0 +1,118 @@
+// Package outbox publishes the events written in the same transaction as the state change they describe,
+// so that an event is published if and only if the transaction commits.
+package outbox
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+)
+
+type EventStatus string
+
+const (
+	EventStatusPending   EventStatus = "PENDING"
+	EventStatusPublished EventStatus = "PUBLISHED"
+	// EventStatusFailed events have exhausted their attempts or failed with a non-retryable error
+	EventStatusFailed EventStatus = "FAILED"
+)
+
+// Event is a row of outbox_events table.
+// Refer db/<name>/migrations for the schema of outbox_events table.
+type Event struct {
+	ID    int64 `gorm:"primaryKey"`
+	Topic string
+	// Key identifies the entity the event is about e.g. order id
+	Key     string          `gorm:"column:event_key"`
+	Payload json.RawMessage `gorm:"type:jsonb"`
+	Headers Headers         `gorm:"type:jsonb"`
+	Status  EventStatus
+	// Attempts is the number of failed attempts to publish the event
+	Attempts      uint
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+func (Event) TableName() string {
+	return "outbox_events"
+}
+
+// Headers are the metadata of an Event e.g. trace context
+type Headers map[string]string
+
+func (h Headers) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+
+	return json.Marshal(h)
+}
+
+func (h *Headers) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		return json.Unmarshal(v, h)
+	case string:
+		return json.Unmarshal([]byte(v), h)
+	default:
+		return errors.New("unsupported type %T for outbox headers", src)
+	}
+}
+
+// NewEvent creates an event with the JSON encoded payload
+func NewEvent(topic, key string, payload any, headers Headers) (*Event, error) {
+	b, err := json.Marshal(payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal payload of %s event", topic)
+	}
+
+	return &Event{
+		Topic:   topic,
+		Key:     key,
+		Payload: b,
+		Headers: headers,
+	}, nil
+}
+
+type Outbox interface {
+	// Enqueue writes the events in the transaction of ctx, refer storage.TxManager.
+	// The events are published by the Relay once the transaction commits, or right away if ctx has no transaction.
+	Enqueue(ctx context.Context, events ...*Event) error
+}
+
+type pgOutbox struct {
+	txManager storage.TxManager
+}
+
+// NewOutbox creates an Outbox backed by outbox_events table
+func NewOutbox(txManager storage.TxManager) Outbox {
+	return &pgOutbox{txManager: txManager}
+}
+
+func (o *pgOutbox) Enqueue(ctx context.Context, events ...*Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	for _, event := range events {
+		event.Status = EventStatusPending
+		event.NextAttemptAt = now
+	}
+
+	if err := o.txManager.DB(ctx).Create(events).Error; err != nil {
+		return errors.Wrap(storage.ClassifyError(err), "failed to enqueue events")
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package outbox
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestWebhookPublisher(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	status := http.StatusOK
+	var got *http.Request
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	publisher := NewWebhookPublisher(srv.Client(), &cfg.Webhook{URL: srv.URL, Secret: "s3cret", Headers: map[string]string{"Authorization": "Bearer t"}})
+	event, err := NewEvent("order.created", "order-1", map[string]string{"id": "order-1"}, Headers{"traceparent": "00-abc"})
+	a.NoError(err)
+	event.ID = 42
+
+	a.NoError(publisher.Publish(context.Background(), event))
+	a.JSONEq(`{"id":"order-1"}`, string(gotBody))
+	a.Equal("42", got.Header.Get(HeaderEventID))
+	a.Equal("order.created", got.Header.Get(HeaderEventTopic))
+	a.Equal("order-1", got.Header.Get(HeaderEventKey))
+	a.Equal("00-abc", got.Header.Get("traceparent"))
+	a.Equal("Bearer t", got.Header.Get("Authorization"))
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(gotBody)
+	a.Equal("sha256="+hex.EncodeToString(mac.Sum(nil)), got.Header.Get(HeaderSignature))
+
+	status = http.StatusBadRequest
+	a.ErrorIs(publisher.Publish(context.Background(), event), errors.ErrPermanent)
+
+	status = http.StatusServiceUnavailable
+	a.ErrorIs(publisher.Publish(context.Background(), event), errors.ErrTransient)
+}
+
+func TestRelay_Publish(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	publisher := NewInMemoryPublisher()
+	relay := NewRelay(nil, publisher, &cfg.Outbox{
+		RetryParams: &cfg.RetryParams{RegularInterval: &cfg.RegularInterval{Interval: time.Minute, MaxAttempts: 2}},
+	}, logger)
+	ctx := context.Background()
+
+	updates := relay.publish(ctx, &Event{ID: 1})
+	a.Equal(EventStatusPublished, updates["status"])
+	a.Len(publisher.Events(), 1)
+
+	publisher.PublishFn = func(context.Context, *Event) error { return errors.E(errors.ErrTransientStr, "broker down") }
+	updates = relay.publish(ctx, &Event{ID: 2, Attempts: 1})
+	a.NotContains(updates, "status")
+	a.EqualValues(2, updates["attempts"])
+	a.WithinDuration(time.Now().Add(time.Minute), updates["next_attempt_at"].(time.Time), time.Second)
+
+	// attempts exhausted
+	updates = relay.publish(ctx, &Event{ID: 2, Attempts: 2})
+	a.Equal(EventStatusFailed, updates["status"])
+
+	publisher.PublishFn = func(context.Context, *Event) error { return errors.E(errors.ErrPermanentStr, "invalid event") }
+	updates = relay.publish(ctx, &Event{ID: 3})
+	a.Equal(EventStatusFailed, updates["status"])
+	a.Len(publisher.Events(), 1)
+
+	// events of a stopped relay are released without charging the attempt
+	stopped, cancel := context.WithCancel(ctx)
+	cancel()
+	updates = relay.publish(stopped, &Event{ID: 4, Attempts: 1})
+	a.Len(updates, 1)
+	a.WithinDuration(time.Now(), updates["next_attempt_at"].(time.Time), time.Second)
+}
+
+func TestRelay_Record(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost sslmode=disable"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	a.NoError(err)
+	var sql string
+	a.NoError(db.Callback().Update().After("*").Register("test:capture", func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	}))
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+	relay := NewRelay(db, NewInMemoryPublisher(), nil, logger)
+
+	// the outcome is not recorded if the lease expired and the event was claimed again
+	leasedUntil := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	a.NoError(relay.record(context.Background(), &Event{ID: 7, NextAttemptAt: leasedUntil}, map[string]any{"status": EventStatusPublished}))
+	a.Contains(sql, `WHERE id = 7 AND status = 'PENDING' AND next_attempt_at = '2024-01-02 03:04:05'`)
+}
@@ -0,0 +1,124 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+const (
+	HeaderEventID    = "X-Event-Id"
+	HeaderEventTopic = "X-Event-Topic"
+	HeaderEventKey   = "X-Event-Key"
+	HeaderSignature  = "X-Signature-256"
+)
+
+// Publisher publishes an event to the downstream e.g. a message broker or a webhook.
+// Events are published at least once, hence the consumers must be idempotent on the event id.
+// Errors matching errors.ErrPermanent, including errors.E with a type which isn't retryable, fail the event without retries.
+type Publisher interface {
+	Publish(ctx context.Context, event *Event) error
+}
+
+type webhookPublisher struct {
+	client *http.Client
+	conf   *cfg.Webhook
+}
+
+// NewWebhookPublisher creates a Publisher which POSTs the payload of the events to the webhook.
+// The id, topic and key of the event are sent as the X-Event-* headers along with the headers of the event.
+// Non 2xx responses fail with the error type of the status code, 4xx failures except 408, 409 and 429 are not retried.
+func NewWebhookPublisher(client *http.Client, conf *cfg.Webhook) Publisher {
+	return &webhookPublisher{
+		client: client,
+		conf:   conf,
+	}
+}
+
+func (p *webhookPublisher) Publish(ctx context.Context, event *Event) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.conf.URL, bytes.NewReader(event.Payload))
+	if err != nil {
+		return errors.E(errors.ErrPermanentStr, "invalid webhook request", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range p.conf.Headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range event.Headers {
+		req.Header.Set(k, v)
+	}
+	req.Header.Set(HeaderEventID, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderEventTopic, event.Topic)
+	if event.Key != "" {
+		req.Header.Set(HeaderEventKey, event.Key)
+	}
+	if p.conf.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(p.conf.Secret))
+		mac.Write(event.Payload)
+		req.Header.Set(HeaderSignature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return errors.E(errors.ErrTransientStr, "webhook call failed", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusRequestTimeout ||
+		resp.StatusCode == http.StatusConflict || resp.StatusCode == http.StatusTooManyRequests
+
+	return errors.E(
+		errors.GetErrorTypeFromHttpStatus(resp.StatusCode),
+		"webhook responded with "+resp.Status,
+		errors.New("%s", body),
+		errors.Retryable(retryable),
+	)
+}
+
+// InMemoryPublisher records the published events, meant for the tests
+type InMemoryPublisher struct {
+	// PublishFn is called before recording the event if set, the event is not recorded if it fails
+	PublishFn func(ctx context.Context, event *Event) error
+
+	mu     sync.Mutex
+	events []*Event
+}
+
+func NewInMemoryPublisher() *InMemoryPublisher {
+	return &InMemoryPublisher{}
+}
+
+func (p *InMemoryPublisher) Publish(ctx context.Context, event *Event) error {
+	if p.PublishFn != nil {
+		if err := p.PublishFn(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns the events published so far in order
+func (p *InMemoryPublisher) Events() []*Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]*Event(nil), p.events...)
+}
@@ -0,0 +1,199 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultLease        = 5 * time.Minute
+	// recordTimeout bounds recording the outcome of an event, which is done even if the relay is stopped
+	recordTimeout = 30 * time.Second
+)
+
+var defaultRetryParams = &cfg.RetryParams{
+	ExponentialBackOff: &cfg.ExponentialBackOff{
+		BaseInterval: time.Second,
+		MaxInterval:  5 * time.Minute,
+		MaxAttempts:  10,
+	},
+}
+
+// Relay publishes the pending events of the outbox through the Publisher.
+// Multiple relays can run concurrently, each event is claimed by a single relay for a lease using FOR UPDATE SKIP LOCKED.
+// Events are published outside of the transaction of the claim, at least once and not necessarily in order.
+type Relay struct {
+	db           *gorm.DB
+	publisher    Publisher
+	logger       log.Logger
+	pollInterval time.Duration
+	batchSize    int
+	lease        time.Duration
+	retryParams  *cfg.RetryParams
+}
+
+func NewRelay(db *gorm.DB, publisher Publisher, conf *cfg.Outbox, logger log.Logger) *Relay {
+	r := &Relay{
+		db:           db,
+		publisher:    publisher,
+		logger:       logger,
+		pollInterval: defaultPollInterval,
+		batchSize:    defaultBatchSize,
+		lease:        defaultLease,
+		retryParams:  defaultRetryParams,
+	}
+
+	if conf != nil {
+		if conf.PollInterval > 0 {
+			r.pollInterval = conf.PollInterval
+		}
+		if conf.BatchSize > 0 {
+			r.batchSize = conf.BatchSize
+		}
+		if conf.Lease > 0 {
+			r.lease = conf.Lease
+		}
+		if conf.RetryParams != nil {
+			r.retryParams = conf.RetryParams
+		}
+	}
+
+	return r
+}
+
+// Run relays the events until ctx is done. Full batches are followed by the next batch immediately,
+// otherwise the relay waits for the poll interval.
+func (r *Relay) Run(ctx context.Context) {
+	for {
+		n, err := r.RelayBatch(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Error(ctx, "failed to relay outbox events", zap.Error(err))
+		}
+
+		if err == nil && n == r.batchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// RelayBatch publishes a batch of the pending events due for an attempt and returns the number of events attempted.
+// Failed events are retried after the backoff of cfg.RetryParams, and are marked FAILED once the attempts are exhausted
+// or the Publisher fails with errors.ErrPermanent. The outcome of each event is recorded on its own.
+func (r *Relay) RelayBatch(ctx context.Context) (int, error) {
+	events, err := r.claim(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var recordErr error
+	for _, event := range events {
+		if err = r.record(ctx, event, r.publish(ctx, event)); err != nil && recordErr == nil {
+			recordErr = err
+		}
+	}
+
+	return len(events), recordErr
+}
+
+// claim leases a batch of the pending events due for an attempt, their next attempt is moved to the end of the lease
+func (r *Relay) claim(ctx context.Context) ([]*Event, error) {
+	now := time.Now()
+	var events []*Event
+	err := r.db.WithContext(ctx).Raw(`UPDATE outbox_events SET next_attempt_at = ?, updated_at = ?
+WHERE id IN (
+    SELECT id FROM outbox_events
+    WHERE status = ? AND next_attempt_at <= ?
+    ORDER BY id
+    LIMIT ?
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *`,
+		now.Add(r.lease), now,
+		EventStatusPending, now,
+		r.batchSize,
+	).Scan(&events).Error
+	if err != nil {
+		return nil, errors.Wrap(storage.ClassifyError(err), "failed to claim outbox events")
+	}
+
+	return events, nil
+}
+
+// record updates the row of the event as per the outcome, unless its lease expired and it was claimed again
+func (r *Relay) record(ctx context.Context, event *Event, updates map[string]any) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+	defer cancel()
+
+	err := r.db.WithContext(ctx).Model(&Event{}).
+		Where("id = ? AND status = ? AND next_attempt_at = ?", event.ID, EventStatusPending, event.NextAttemptAt).
+		Updates(updates).Error
+	if err != nil {
+		return errors.Wrap(storage.ClassifyError(err), "failed to record outcome of outbox event %d", event.ID)
+	}
+
+	return nil
+}
+
+// publish publishes the event and returns the updates of its row as per the outcome.
+// Events not published because the relay is stopped are released for an attempt without charging it.
+func (r *Relay) publish(ctx context.Context, event *Event) map[string]any {
+	if ctx.Err() != nil {
+		return map[string]any{"next_attempt_at": time.Now()}
+	}
+
+	err := r.publisher.Publish(ctx, event)
+	if err != nil && ctx.Err() != nil {
+		return map[string]any{"next_attempt_at": time.Now()}
+	}
+	if err == nil {
+		return map[string]any{
+			"status":       EventStatusPublished,
+			"published_at": time.Now(),
+		}
+	}
+
+	attempts := event.Attempts + 1
+	updates := map[string]any{
+		"attempts":   attempts,
+		"last_error": err.Error(),
+	}
+
+	if errors.Is(err, errors.ErrPermanent) || attempts > r.retryParams.GetMaxAttempts() {
+		r.logger.Error(ctx, "outbox event failed", zap.Int64("id", event.ID), zap.String("topic", event.Topic),
+			zap.Uint("attempts", attempts), zap.Error(err))
+		updates["status"] = EventStatusFailed
+		return updates
+	}
+
+	r.logger.Warn(ctx, "outbox event publish failed, will be retried", zap.Int64("id", event.ID),
+		zap.String("topic", event.Topic), zap.Uint("attempts", attempts), zap.Error(err))
+	updates["next_attempt_at"] = time.Now().Add(r.retryParams.GetBackoff(attempts))
+	return updates
+}
+
+// DeletePublished deletes the events published before the time and returns the number of deleted events
+func (r *Relay) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	res := r.db.WithContext(ctx).
+		Where("status = ? AND published_at < ?", EventStatusPublished, before).
+		Delete(&Event{})
+	if res.Error != nil {
+		return 0, errors.Wrap(storage.ClassifyError(res.Error), "failed to delete published outbox events")
+	}
+
+	return res.RowsAffected, nil
+}
@@ -0,0 +1,151 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/json"
+)
+
+const (
+	DefaultPageSize = 50
+	MaxPageSize     = 1000
+)
+
+type FilterOp string
+
+const (
+	FilterOpEq        FilterOp = "="
+	FilterOpNe        FilterOp = "<>"
+	FilterOpGt        FilterOp = ">"
+	FilterOpGte       FilterOp = ">="
+	FilterOpLt        FilterOp = "<"
+	FilterOpLte       FilterOp = "<="
+	FilterOpIn        FilterOp = "IN"
+	FilterOpNotIn     FilterOp = "NOT IN"
+	FilterOpLike      FilterOp = "LIKE"
+	FilterOpIsNull    FilterOp = "IS NULL"
+	FilterOpIsNotNull FilterOp = "IS NOT NULL"
+)
+
+// Filter is a condition on a field of the model, fields are named by their column or struct field name
+type Filter struct {
+	Field string
+	Op    FilterOp
+	// Value is a slice for FilterOpIn and FilterOpNotIn, and is ignored for FilterOpIsNull and FilterOpIsNotNull
+	Value any
+}
+
+func Eq(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpEq, Value: value}
+}
+
+func Ne(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpNe, Value: value}
+}
+
+func Gt(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpGt, Value: value}
+}
+
+func Gte(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpGte, Value: value}
+}
+
+func Lt(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpLt, Value: value}
+}
+
+func Lte(field string, value any) Filter {
+	return Filter{Field: field, Op: FilterOpLte, Value: value}
+}
+
+func In(field string, values any) Filter {
+	return Filter{Field: field, Op: FilterOpIn, Value: values}
+}
+
+func NotIn(field string, values any) Filter {
+	return Filter{Field: field, Op: FilterOpNotIn, Value: values}
+}
+
+func Like(field, pattern string) Filter {
+	return Filter{Field: field, Op: FilterOpLike, Value: pattern}
+}
+
+func IsNull(field string) Filter {
+	return Filter{Field: field, Op: FilterOpIsNull}
+}
+
+func IsNotNull(field string) Filter {
+	return Filter{Field: field, Op: FilterOpIsNotNull}
+}
+
+// Sort orders the results by a field of the model, fields are named by their column or struct field name
+type Sort struct {
+	Field string
+	Desc  bool
+}
+
+func Asc(field string) Sort {
+	return Sort{Field: field}
+}
+
+func Desc(field string) Sort {
+	return Sort{Field: field, Desc: true}
+}
+
+type ListOptions struct {
+	// Filters are ANDed
+	Filters []Filter
+	// Sorts are applied in order, the primary key is always the last sort to make the order deterministic.
+	// Sort fields must not be nullable for the keyset pagination through PageToken.
+	Sorts []Sort
+	// DefaultPageSize if not set, capped at MaxPageSize
+	PageSize int
+	// Offset skips the rows for the offset pagination, can't be used along with PageToken
+	Offset int
+	// PageToken is the NextPageToken of the previous page for the keyset pagination.
+	// Filters and Sorts must be the same as the ones of the previous page.
+	PageToken string
+	// IncludeDeleted lists the soft deleted rows as well
+	IncludeDeleted bool
+}
+
+type Page[T any] struct {
+	Items []*T
+	// NextPageToken is set if there are more items, pass it as the PageToken to get the next page
+	NextPageToken string
+}
+
+func (o *ListOptions) getPageSize() int {
+	switch {
+	case o.PageSize <= 0:
+		return DefaultPageSize
+	case o.PageSize > MaxPageSize:
+		return MaxPageSize
+	default:
+		return o.PageSize
+	}
+}
+
+// encodePageToken encodes the values of the sort fields of the last item of the page
+func encodePageToken(values []any) (string, error) {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func decodePageToken(token string) ([]json.RawMessage, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var values []json.RawMessage
+	if err = json.Unmarshal(b, &values); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
@@ -0,0 +1,266 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	logpkg "github.com/nitesh237/go-server-template/pkg/log"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const (
+	replicaResolverName       = "storage:replica_resolver"
+	fromReplicaSetting        = "storage:from_replica"
+	defaultHealthCheckTimeout = time.Second
+	defaultEjectionCooldown   = 30 * time.Second
+)
+
+// FromReplica marks the queries of db to be served by a replica, e.g. db.Scopes(storage.FromReplica).Find(&users).
+// Queries in a transaction and locking queries are always served by the connection they are issued on.
+func FromReplica(db *gorm.DB) *gorm.DB {
+	return db.Set(fromReplicaSetting, true)
+}
+
+// CloseDB closes the pool of the primary along with the pools of the replicas and stops their health checks.
+// All the pools are closed even if closing one of them fails, the first error is returned.
+func CloseDB(db *gorm.DB) error {
+	var closeErr error
+	if plugin, ok := db.Config.Plugins[replicaResolverName]; ok {
+		closeErr = plugin.(*replicaResolver).Close()
+	}
+
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if closeErr == nil {
+		closeErr = err
+	}
+
+	return closeErr
+}
+
+type replica struct {
+	db   *sql.DB
+	host string
+	// ejectedUntil is the unix nano time until which the replica is ejected, zero if it's healthy
+	ejectedUntil atomic.Int64
+}
+
+func (rep *replica) isEjected(now time.Time) bool {
+	return rep.ejectedUntil.Load() > now.UnixNano()
+}
+
+// replicaResolver is the gorm.ConnPool of the primary which begins the read only transactions on a replica.
+// As a gorm.Plugin it routes the queries marked with FromReplica to a replica.
+type replicaResolver struct {
+	*sql.DB
+	replicas []*replica
+	policy   cfg.ReplicaPolicy
+	next     atomic.Uint64
+	logger   logpkg.Logger
+
+	healthCheckInterval time.Duration
+	healthCheckTimeout  time.Duration
+	ejectionCooldown    time.Duration
+	stop                chan struct{}
+	closeOnce           sync.Once
+}
+
+func newReplicaResolver(primary *sql.DB, dbConf *cfg.Storage, logger logpkg.Logger) (*replicaResolver, error) {
+	r := &replicaResolver{
+		DB:                 primary,
+		policy:             cfg.RoundRobinReplicaPolicy,
+		logger:             logger,
+		healthCheckTimeout: defaultHealthCheckTimeout,
+		ejectionCooldown:   defaultEjectionCooldown,
+		stop:               make(chan struct{}),
+	}
+
+	if routing := dbConf.ReplicaRouting; routing != nil {
+		if routing.Policy != "" {
+			r.policy = routing.Policy
+		}
+		r.healthCheckInterval = routing.HealthCheckInterval
+		if routing.HealthCheckTimeout != 0 {
+			r.healthCheckTimeout = routing.HealthCheckTimeout
+		}
+		if routing.EjectionCooldown > 0 {
+			r.ejectionCooldown = routing.EjectionCooldown
+		}
+	}
+
+	switch r.policy {
+	case cfg.RoundRobinReplicaPolicy, cfg.LeastConnectionsReplicaPolicy:
+	default:
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "unknown replica policy %s", r.policy)
+	}
+
+	for _, replicaConf := range dbConf.Replicas {
+		sqlDB, err := openPostgresPool(replicaConf.PgDsn, dbConf)
+		if err != nil {
+			_ = r.Close()
+			return nil, errors.Wrap(err, "failed to open replica %s", replicaConf.PgDsn.Host)
+		}
+		setPoolLimits(sqlDB, replicaConf.MaxOpenConn, replicaConf.MaxIdleConn, replicaConf.MaxConnTtl)
+
+		// host the pool connects to, replicas aren't overridden by DBHostEnv unlike the primary
+		host := GetPgDsnUrl(replicaConf.PgDsn).Hostname()
+		r.replicas = append(r.replicas, &replica{db: sqlDB, host: host})
+	}
+
+	return r, nil
+}
+
+func (r *replicaResolver) Name() string {
+	return replicaResolverName
+}
+
+func (r *replicaResolver) Initialize(db *gorm.DB) error {
+	db.ConnPool = r
+	db.Statement.ConnPool = r
+
+	if err := db.Callback().Query().Before("*").Register(replicaResolverName, r.routeToReplica); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("*").Register(replicaResolverName, r.routeToReplica); err != nil {
+		return err
+	}
+
+	if r.healthCheckInterval > 0 {
+		go r.runHealthChecks()
+	}
+
+	return nil
+}
+
+// GetDBConn returns the pool of the primary, used by gorm.DB.DB
+func (r *replicaResolver) GetDBConn() (*sql.DB, error) {
+	return r.DB, nil
+}
+
+// BeginTx begins the read only transactions on a replica and the rest on the primary.
+// A replica failing to begin the transaction is ejected for the cooldown and the transaction is begun on the primary instead.
+func (r *replicaResolver) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	if opts == nil || !opts.ReadOnly {
+		return r.DB.BeginTx(ctx, opts)
+	}
+
+	rep := r.resolve()
+	if rep == nil {
+		return r.DB.BeginTx(ctx, opts)
+	}
+
+	tx, err := rep.db.BeginTx(ctx, opts)
+	if err != nil && ctx.Err() == nil {
+		r.eject(rep, err)
+		return r.DB.BeginTx(ctx, opts)
+	}
+
+	return tx, err
+}
+
+// Close stops the health checks and closes the pools of all the replicas, the first error is returned
+func (r *replicaResolver) Close() error {
+	r.closeOnce.Do(func() {
+		close(r.stop)
+	})
+
+	var closeErr error
+	for _, rep := range r.replicas {
+		if err := rep.db.Close(); err != nil && closeErr == nil {
+			closeErr = errors.Wrap(err, "failed to close replica %s", rep.host)
+		}
+	}
+
+	return closeErr
+}
+
+func (r *replicaResolver) routeToReplica(db *gorm.DB) {
+	if _, ok := db.Statement.Settings.Load(fromReplicaSetting); !ok {
+		return
+	}
+	if _, ok := db.Statement.ConnPool.(gorm.TxCommitter); ok {
+		return
+	}
+	if _, locking := db.Statement.Clauses["FOR"]; locking {
+		return
+	}
+
+	if rep := r.resolve(); rep != nil {
+		db.Statement.ConnPool = rep.db
+	}
+}
+
+// resolve picks one of the healthy replicas as per the policy, nil if all of them are ejected
+func (r *replicaResolver) resolve() *replica {
+	now := time.Now()
+	healthy := make([]*replica, 0, len(r.replicas))
+	for _, rep := range r.replicas {
+		if !rep.isEjected(now) {
+			healthy = append(healthy, rep)
+		}
+	}
+
+	if len(healthy) == 0 {
+		return nil
+	}
+
+	if r.policy == cfg.LeastConnectionsReplicaPolicy {
+		least := healthy[0]
+		for _, rep := range healthy[1:] {
+			if rep.db.Stats().InUse < least.db.Stats().InUse {
+				least = rep
+			}
+		}
+		return least
+	}
+
+	return healthy[r.next.Add(1)%uint64(len(healthy))]
+}
+
+// eject ejects the replica for the cooldown, after which it's tried again unless a health check fails meanwhile
+func (r *replicaResolver) eject(rep *replica, err error) {
+	now := time.Now()
+	wasEjected := rep.isEjected(now)
+	rep.ejectedUntil.Store(now.Add(r.ejectionCooldown).UnixNano())
+	if !wasEjected {
+		r.logger.WarnNoCtx("replica ejected", zap.String("host", rep.host), zap.Duration("cooldown", r.ejectionCooldown), zap.Error(err))
+	}
+}
+
+func (r *replicaResolver) runHealthChecks() {
+	ticker := time.NewTicker(r.healthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+			for _, rep := range r.replicas {
+				r.checkHealth(rep)
+			}
+		}
+	}
+}
+
+func (r *replicaResolver) checkHealth(rep *replica) {
+	ctx, cancel := context.WithTimeout(context.Background(), r.healthCheckTimeout)
+	defer cancel()
+
+	if err := rep.db.PingContext(ctx); err != nil {
+		r.eject(rep, err)
+		return
+	}
+
+	if rep.ejectedUntil.Swap(0) > time.Now().UnixNano() {
+		r.logger.InfoNoCtx("replica reinstated", zap.String("host", rep.host))
+	}
+}
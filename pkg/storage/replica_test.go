@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/log"
+	"github.com/nitesh237/go-server-template/pkg/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func newTestReplicaResolver(a *require.Assertions, policy cfg.ReplicaPolicy) (*gorm.DB, *replicaResolver) {
+	dbConf := &cfg.Storage{
+		PgDsn:      &cfg.PgDsn{Host: "primary", Port: 5432, SSLMode: DBSSLModeDisable},
+		GormV2Conf: &cfg.GormV2Conf{},
+		Replicas: []*cfg.Replica{
+			{PgDsn: &cfg.PgDsn{Host: "replica-1", Port: 5432, SSLMode: DBSSLModeDisable}},
+			{PgDsn: &cfg.PgDsn{Host: "replica-2", Port: 5432, SSLMode: DBSSLModeDisable}},
+		},
+		ReplicaRouting: &cfg.ReplicaRouting{Policy: policy},
+	}
+
+	primary, err := openPostgresPool(dbConf.PgDsn, dbConf)
+	a.NoError(err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: primary}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	a.NoError(err)
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	resolver, err := newReplicaResolver(primary, dbConf, logger)
+	a.NoError(err)
+	a.NoError(db.Use(resolver))
+
+	return db, resolver
+}
+
+func TestReplicaResolver_Routing(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	db, resolver := newTestReplicaResolver(a, cfg.RoundRobinReplicaPolicy)
+	defer CloseDB(db)
+
+	type user struct{ ID int }
+
+	tx := db.Find(&[]user{})
+	a.Equal(resolver, tx.Statement.ConnPool)
+
+	first := db.Scopes(FromReplica).Find(&[]user{}).Statement.ConnPool
+	second := db.Scopes(FromReplica).Find(&[]user{}).Statement.ConnPool
+	a.ElementsMatch([]any{resolver.replicas[0].db, resolver.replicas[1].db}, []any{first, second})
+
+	tx = db.Scopes(FromReplica).Clauses(clause.Locking{Strength: "UPDATE"}).Find(&[]user{})
+	a.Equal(resolver, tx.Statement.ConnPool)
+
+	resolver.eject(resolver.replicas[0], io.ErrUnexpectedEOF)
+	a.Equal(resolver.replicas[1], resolver.resolve())
+	resolver.eject(resolver.replicas[1], io.ErrUnexpectedEOF)
+	a.Nil(resolver.resolve())
+
+	tx = db.Scopes(FromReplica).Find(&[]user{})
+	a.Equal(resolver, tx.Statement.ConnPool)
+
+	sqlDB, err := db.DB()
+	a.NoError(err)
+	a.Equal(resolver.DB, sqlDB)
+}
+
+// not parallel as it sets the env
+func TestReplicaResolver_HostEnv(t *testing.T) {
+	a := require.New(t)
+	t.Setenv(DBHostEnv, "primary.internal")
+	t.Setenv("REPLICA_2_HOST", "replica-2.internal")
+
+	dbConf := &cfg.Storage{
+		PgDsn:      &cfg.PgDsn{Host: "primary", Port: 5432, SSLMode: DBSSLModeDisable},
+		GormV2Conf: &cfg.GormV2Conf{},
+		Replicas: []*cfg.Replica{
+			{PgDsn: &cfg.PgDsn{Host: "replica-1", Port: 5432, SSLMode: DBSSLModeDisable}},
+			{PgDsn: &cfg.PgDsn{Host: "replica-2", HostEnv: "REPLICA_2_HOST", Port: 5432, SSLMode: DBSSLModeDisable}},
+		},
+	}
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	resolver, err := newReplicaResolver(sql.OpenDB((&fakeReplicaServer{}).connector()), dbConf, logger)
+	a.NoError(err)
+	defer resolver.Close()
+
+	// DB_HOST overrides only the primary, the replicas keep their hosts unless their HostEnv is set
+	a.Equal("replica-1", resolver.replicas[0].host)
+	a.Equal("replica-2.internal", resolver.replicas[1].host)
+	a.Equal("primary.internal", GetPgDsnUrl(primaryPgDsn(dbConf.PgDsn)).Hostname())
+}
+
+func TestReplicaResolver_LeastConnections(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	db, resolver := newTestReplicaResolver(a, cfg.LeastConnectionsReplicaPolicy)
+	defer CloseDB(db)
+
+	a.Equal(resolver.replicas[0], resolver.resolve())
+	resolver.eject(resolver.replicas[0], io.ErrUnexpectedEOF)
+	a.Equal(resolver.replicas[1], resolver.resolve())
+}
+
+func TestReplicaResolver_Ejection(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+
+	logger, err := log.NewZapLogger(cfg.Test, &cfg.Logging{})
+	a.NoError(err)
+
+	primary, replicaServer := &fakeReplicaServer{}, &fakeReplicaServer{}
+	replicaServer.down.Store(true)
+	resolver := &replicaResolver{
+		DB:                 sql.OpenDB(primary.connector()),
+		replicas:           []*replica{{db: sql.OpenDB(replicaServer.connector()), host: "replica-1"}},
+		policy:             cfg.RoundRobinReplicaPolicy,
+		logger:             logger,
+		healthCheckTimeout: time.Second,
+		ejectionCooldown:   50 * time.Millisecond,
+		stop:               make(chan struct{}),
+	}
+	defer resolver.Close()
+
+	// the replica failing to begin is ejected, the transaction falls back to the primary
+	tx, err := resolver.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	a.NoError(err)
+	a.NoError(tx.(*sql.Tx).Rollback())
+	a.EqualValues(1, primary.begun.Load())
+	a.Nil(resolver.resolve())
+
+	// the replica is tried again after the cooldown, even without health checks
+	a.Eventually(func() bool { return resolver.resolve() != nil }, time.Second, 10*time.Millisecond)
+	replicaServer.down.Store(false)
+	tx, err = resolver.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	a.NoError(err)
+	a.NoError(tx.(*sql.Tx).Rollback())
+	a.EqualValues(1, replicaServer.begun.Load())
+
+	// health checks eject and reinstate the replica
+	replicaServer.down.Store(true)
+	resolver.checkHealth(resolver.replicas[0])
+	a.Nil(resolver.resolve())
+	replicaServer.down.Store(false)
+	resolver.checkHealth(resolver.replicas[0])
+	a.NotNil(resolver.resolve())
+
+	// writes always go to the primary
+	tx, err = resolver.BeginTx(ctx, nil)
+	a.NoError(err)
+	a.NoError(tx.(*sql.Tx).Rollback())
+	a.EqualValues(2, primary.begun.Load())
+}
+
+func TestReplicaResolver_Close(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	db, resolver := newTestReplicaResolver(a, cfg.RoundRobinReplicaPolicy)
+	a.NoError(CloseDB(db))
+	for _, rep := range resolver.replicas {
+		a.ErrorContains(rep.db.Ping(), "database is closed")
+	}
+	a.ErrorContains(resolver.DB.Ping(), "database is closed")
+	a.NoError(resolver.Close())
+}
+
+// fakeReplicaServer is a storagetest.Connector which fails to connect while it's down
+type fakeReplicaServer struct {
+	down  atomic.Bool
+	begun atomic.Int32
+}
+
+func (s *fakeReplicaServer) connector() *storagetest.Connector {
+	return &storagetest.Connector{
+		OnConnect: func(context.Context) error {
+			if s.down.Load() {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		},
+		OnBegin: func(*storagetest.Conn) error {
+			if s.down.Load() {
+				return driver.ErrBadConn
+			}
+			s.begun.Add(1)
+			return nil
+		},
+		OnPing: func(*storagetest.Conn) error {
+			if s.down.Load() {
+				return driver.ErrBadConn
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,327 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"sync"
+
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+// Repository provides the CRUD operations of the gorm model T having a single primary key.
+// Operations run in the transaction of ctx if one is active, refer TxManager.
+// Missing rows fail with errors.ErrRecordNotFound and the constraint violations as per ClassifyError
+// e.g. errors.ErrAlreadyExists for the duplicates.
+type Repository[T any] interface {
+	Create(ctx context.Context, entity *T) error
+	Get(ctx context.Context, id any) (*T, error)
+	// Update updates the fields of the mask, all the fields except the primary key and the creation time if the mask is empty.
+	// Fields are named by their column or struct field name, auto update time fields are always updated.
+	Update(ctx context.Context, entity *T, fieldMask ...string) error
+	// Delete soft deletes the row if T has a gorm.DeletedAt field, hard deletes it otherwise
+	Delete(ctx context.Context, id any) error
+	HardDelete(ctx context.Context, id any) error
+	List(ctx context.Context, opts *ListOptions) (*Page[T], error)
+}
+
+type repository[T any] struct {
+	txManager TxManager
+	schema    *schema.Schema
+	pk        *schema.Field
+}
+
+// NewRepository creates a Repository of T over the DB of txManager
+func NewRepository[T any](txManager TxManager) (Repository[T], error) {
+	sch, err := schema.Parse(new(T), &sync.Map{}, txManager.DB(context.Background()).NamingStrategy)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse schema of %T", *new(T))
+	}
+
+	if len(sch.PrimaryFields) != 1 {
+		return nil, errors.Wrap(errors.ErrInvalidArgument, "%s must have a single primary key", sch.Name)
+	}
+
+	return &repository[T]{
+		txManager: txManager,
+		schema:    sch,
+		pk:        sch.PrimaryFields[0],
+	}, nil
+}
+
+func (r *repository[T]) Create(ctx context.Context, entity *T) error {
+	if err := r.txManager.DB(ctx).Create(entity).Error; err != nil {
+		return r.mapError(err)
+	}
+
+	return nil
+}
+
+func (r *repository[T]) Get(ctx context.Context, id any) (*T, error) {
+	entity := new(T)
+	if err := r.txManager.DB(ctx).Where(r.pkEq(id)).Take(entity).Error; err != nil {
+		return nil, r.mapError(err)
+	}
+
+	return entity, nil
+}
+
+func (r *repository[T]) Update(ctx context.Context, entity *T, fieldMask ...string) error {
+	if _, zero := r.pk.ValueOf(ctx, reflect.ValueOf(entity).Elem()); zero {
+		return errors.E(errors.ErrInvalidArgumentStr, "primary key must be set for update", errors.Details{"table": r.schema.Table})
+	}
+
+	tx := r.txManager.DB(ctx).Model(entity)
+	if len(fieldMask) == 0 {
+		omit := []string{clause.Associations, r.pk.DBName}
+		for _, field := range r.schema.Fields {
+			if field.AutoCreateTime > 0 {
+				omit = append(omit, field.DBName)
+			}
+		}
+		tx = tx.Select("*").Omit(omit...)
+	} else {
+		columns := make([]string, 0, len(fieldMask)+1)
+		for _, name := range fieldMask {
+			field, err := r.lookUpField(name)
+			if err != nil {
+				return err
+			}
+			columns = append(columns, field.DBName)
+		}
+		for _, field := range r.schema.Fields {
+			if field.AutoUpdateTime > 0 {
+				columns = append(columns, field.DBName)
+			}
+		}
+		tx = tx.Select(columns)
+	}
+
+	res := tx.Updates(entity)
+	if res.Error != nil {
+		return r.mapError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return r.mapError(gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+func (r *repository[T]) Delete(ctx context.Context, id any) error {
+	return r.delete(r.txManager.DB(ctx), id)
+}
+
+func (r *repository[T]) HardDelete(ctx context.Context, id any) error {
+	return r.delete(r.txManager.DB(ctx).Unscoped(), id)
+}
+
+func (r *repository[T]) delete(db *gorm.DB, id any) error {
+	res := db.Where(r.pkEq(id)).Delete(new(T))
+	if res.Error != nil {
+		return r.mapError(res.Error)
+	}
+	if res.RowsAffected == 0 {
+		return r.mapError(gorm.ErrRecordNotFound)
+	}
+
+	return nil
+}
+
+func (r *repository[T]) List(ctx context.Context, opts *ListOptions) (*Page[T], error) {
+	if opts == nil {
+		opts = &ListOptions{}
+	}
+	if opts.Offset < 0 || (opts.Offset > 0 && opts.PageToken != "") {
+		return nil, errors.E(errors.ErrInvalidArgumentStr, "offset must be non negative and can't be used along with page token")
+	}
+
+	tx := r.txManager.DB(ctx).Model(new(T))
+	if opts.IncludeDeleted {
+		tx = tx.Unscoped()
+	}
+
+	for _, filter := range opts.Filters {
+		expr, err := r.filterExpr(filter)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(expr)
+	}
+
+	sortFields, err := r.sortFields(opts.Sorts)
+	if err != nil {
+		return nil, err
+	}
+	for i, field := range sortFields {
+		tx = tx.Order(clause.OrderByColumn{Column: r.column(field), Desc: r.isDesc(opts.Sorts, i)})
+	}
+
+	if opts.PageToken != "" {
+		expr, err := r.keysetExpr(opts, sortFields)
+		if err != nil {
+			return nil, err
+		}
+		tx = tx.Where(expr)
+	}
+
+	pageSize := opts.getPageSize()
+	var items []*T
+	// an extra item is fetched to know if there are more items
+	if err = tx.Offset(opts.Offset).Limit(pageSize + 1).Find(&items).Error; err != nil {
+		return nil, r.mapError(err)
+	}
+
+	page := &Page[T]{Items: items}
+	if len(items) <= pageSize {
+		return page, nil
+	}
+
+	page.Items = items[:pageSize]
+	last := reflect.ValueOf(page.Items[pageSize-1]).Elem()
+	values := make([]any, 0, len(sortFields))
+	for _, field := range sortFields {
+		v, _ := field.ValueOf(ctx, last)
+		values = append(values, v)
+	}
+
+	if page.NextPageToken, err = encodePageToken(values); err != nil {
+		return nil, errors.Wrap(err, "failed to encode page token")
+	}
+
+	return page, nil
+}
+
+// sortFields resolves the fields of the sorts, appending the primary key as the tie-breaker
+func (r *repository[T]) sortFields(sorts []Sort) ([]*schema.Field, error) {
+	fields := make([]*schema.Field, 0, len(sorts)+1)
+	hasPk := false
+	for _, sort := range sorts {
+		field, err := r.lookUpField(sort.Field)
+		if err != nil {
+			return nil, err
+		}
+		hasPk = hasPk || field == r.pk
+		fields = append(fields, field)
+	}
+
+	if !hasPk {
+		fields = append(fields, r.pk)
+	}
+
+	return fields, nil
+}
+
+func (r *repository[T]) isDesc(sorts []Sort, i int) bool {
+	return i < len(sorts) && sorts[i].Desc
+}
+
+// keysetExpr builds the condition selecting the rows after the page token in the sort order
+// i.e. (a > va) OR (a = va AND b > vb) OR ...
+func (r *repository[T]) keysetExpr(opts *ListOptions, sortFields []*schema.Field) (clause.Expression, error) {
+	rawValues, err := decodePageToken(opts.PageToken)
+	if err != nil || len(rawValues) != len(sortFields) {
+		return nil, errors.E(errors.ErrInvalidArgumentStr, "invalid page token")
+	}
+
+	values := make([]any, 0, len(rawValues))
+	for i, field := range sortFields {
+		v := reflect.New(field.FieldType)
+		if err = json.Unmarshal(rawValues[i], v.Interface()); err != nil {
+			return nil, errors.E(errors.ErrInvalidArgumentStr, "invalid page token", err)
+		}
+		values = append(values, v.Elem().Interface())
+	}
+
+	ors := make([]clause.Expression, 0, len(sortFields))
+	for i, field := range sortFields {
+		ands := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, clause.Eq{Column: r.column(sortFields[j]), Value: values[j]})
+		}
+
+		if r.isDesc(opts.Sorts, i) {
+			ands = append(ands, clause.Lt{Column: r.column(field), Value: values[i]})
+		} else {
+			ands = append(ands, clause.Gt{Column: r.column(field), Value: values[i]})
+		}
+		ors = append(ors, clause.And(ands...))
+	}
+
+	return clause.Or(ors...), nil
+}
+
+func (r *repository[T]) filterExpr(filter Filter) (clause.Expression, error) {
+	field, err := r.lookUpField(filter.Field)
+	if err != nil {
+		return nil, err
+	}
+
+	column := r.column(field)
+	switch filter.Op {
+	case FilterOpEq:
+		return clause.Eq{Column: column, Value: filter.Value}, nil
+	case FilterOpNe:
+		return clause.Neq{Column: column, Value: filter.Value}, nil
+	case FilterOpGt:
+		return clause.Gt{Column: column, Value: filter.Value}, nil
+	case FilterOpGte:
+		return clause.Gte{Column: column, Value: filter.Value}, nil
+	case FilterOpLt:
+		return clause.Lt{Column: column, Value: filter.Value}, nil
+	case FilterOpLte:
+		return clause.Lte{Column: column, Value: filter.Value}, nil
+	case FilterOpLike:
+		return clause.Like{Column: column, Value: filter.Value}, nil
+	case FilterOpIsNull:
+		return clause.Eq{Column: column}, nil
+	case FilterOpIsNotNull:
+		return clause.Neq{Column: column}, nil
+	case FilterOpIn, FilterOpNotIn:
+		v := reflect.ValueOf(filter.Value)
+		if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+			return nil, errors.E(errors.ErrInvalidArgumentStr, "value of IN filter must be a slice", errors.Details{"field": filter.Field})
+		}
+
+		values := make([]any, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i).Interface())
+		}
+
+		if filter.Op == FilterOpNotIn {
+			return clause.Not(clause.IN{Column: column, Values: values}), nil
+		}
+		return clause.IN{Column: column, Values: values}, nil
+	default:
+		return nil, errors.E(errors.ErrInvalidArgumentStr, "unknown filter op", errors.Details{"field": filter.Field, "op": string(filter.Op)})
+	}
+}
+
+// lookUpField resolves the field by its column or struct field name, only the fields of the table can be used
+func (r *repository[T]) lookUpField(name string) (*schema.Field, error) {
+	field := r.schema.LookUpField(name)
+	if field == nil || field.DBName == "" {
+		return nil, errors.E(errors.ErrInvalidArgumentStr, "unknown field", errors.Details{"field": name, "table": r.schema.Table})
+	}
+
+	return field, nil
+}
+
+func (r *repository[T]) column(field *schema.Field) clause.Column {
+	return clause.Column{Table: clause.CurrentTable, Name: field.DBName}
+}
+
+func (r *repository[T]) pkEq(id any) clause.Expression {
+	return clause.Eq{Column: r.column(r.pk), Value: id}
+}
+
+func (r *repository[T]) mapError(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return errors.E(errors.ErrRecordNotFoundStr, err, errors.Details{"table": r.schema.Table})
+	}
+
+	return ClassifyError(err)
+}
@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type testOrder struct {
+	ID        int64
+	Status    string
+	Amount    int
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	DeletedAt gorm.DeletedAt
+}
+
+// newDryRunRepository returns a repository over a DB which captures the SQL instead of executing it
+func newDryRunRepository(a *require.Assertions) (Repository[testOrder], *string) {
+	pool, err := openPostgresPool(&cfg.PgDsn{Host: "localhost", Port: 5432, SSLMode: DBSSLModeDisable}, &cfg.Storage{GormV2Conf: &cfg.GormV2Conf{}})
+	a.NoError(err)
+
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	a.NoError(err)
+
+	var sql string
+	capture := func(tx *gorm.DB) {
+		sql = tx.Dialector.Explain(tx.Statement.SQL.String(), tx.Statement.Vars...)
+	}
+	a.NoError(db.Callback().Query().After("*").Register("test:capture", capture))
+	a.NoError(db.Callback().Update().After("*").Register("test:capture", capture))
+	a.NoError(db.Callback().Delete().After("*").Register("test:capture", capture))
+
+	repo, err := NewRepository[testOrder](NewTxManager(db, nil))
+	a.NoError(err)
+
+	return repo, &sql
+}
+
+func TestRepository_List(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+	repo, sql := newDryRunRepository(a)
+
+	token, err := encodePageToken([]any{100, int64(7)})
+	a.NoError(err)
+
+	_, err = repo.List(ctx, &ListOptions{
+		Filters:   []Filter{Eq("status", "PAID"), In("Amount", []int{100, 200}), IsNull("deleted_at")},
+		Sorts:     []Sort{Desc("amount")},
+		PageSize:  10,
+		PageToken: token,
+	})
+	a.NoError(err)
+	a.Equal(`SELECT * FROM "test_orders" WHERE "test_orders"."status" = 'PAID' AND "test_orders"."amount" IN (100,200) `+
+		`AND "test_orders"."deleted_at" IS NULL AND ("test_orders"."amount" < 100 OR ("test_orders"."amount" = 100 AND "test_orders"."id" > 7)) `+
+		`AND "test_orders"."deleted_at" IS NULL ORDER BY "test_orders"."amount" DESC,"test_orders"."id" LIMIT 11`, *sql)
+
+	_, err = repo.List(ctx, &ListOptions{Sorts: []Sort{Asc("unknown")}})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+	_, err = repo.List(ctx, &ListOptions{PageToken: "invalid"})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+	_, err = repo.List(ctx, &ListOptions{PageToken: token, Offset: 10})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+}
+
+func TestRepository_UpdateAndDelete(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+	ctx := context.Background()
+	repo, sql := newDryRunRepository(a)
+
+	// nothing is affected in the dry run
+	err := repo.Update(ctx, &testOrder{ID: 7, Status: "REFUNDED"}, "status")
+	a.ErrorIs(err, errors.ErrRecordNotFound)
+	a.Regexp(`^UPDATE "test_orders" SET "status"='REFUNDED',"updated_at"='.*' WHERE "test_orders"."deleted_at" IS NULL AND "id" = 7$`, *sql)
+
+	err = repo.Update(ctx, &testOrder{Status: "REFUNDED"})
+	a.ErrorIs(err, errors.ErrInvalidArgument)
+
+	err = repo.Delete(ctx, 7)
+	a.ErrorIs(err, errors.ErrRecordNotFound)
+	a.Regexp(`^UPDATE "test_orders" SET "deleted_at"='.*' WHERE "test_orders"."id" = 7 AND "test_orders"."deleted_at" IS NULL$`, *sql)
+
+	err = repo.HardDelete(ctx, 7)
+	a.ErrorIs(err, errors.ErrRecordNotFound)
+	a.Equal(`DELETE FROM "test_orders" WHERE "test_orders"."id" = 7`, *sql)
+}
@@ -1,14 +1,21 @@
 package storage
 
 import (
+	"database/sql"
 	"log"
 	"net"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
 	logpkg "github.com/nitesh237/go-server-template/pkg/log"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -30,18 +37,25 @@ const (
 	StatementTimeout  = "statement_timeout"
 )
 
+const (
+	LockTimeout                     = "lock_timeout"
+	IdleInTransactionSessionTimeout = "idle_in_transaction_session_timeout"
+	SearchPath                      = "search_path"
+)
+
+// DBHostEnv is the env var overriding the host of the primary if its HostEnv is not set
+const DBHostEnv = "DB_HOST"
+
 const (
 	DBSSLModeDisable    = "disable"
 	DBSSLModeVerifyFull = "verify-full"
 	DBSSLModeRequired   = "required"
 )
 
-func NewPostgresDB(dbConf *cfg.Storage, loger logpkg.Logger) (*gorm.DB, error) {
-	connString, err := GetPgDbDsnStringFromDBConf(dbConf)
-	if err != nil {
-		return nil, err
-	}
+// names of the run-time parameters e.g. timezone, pg_trgm.similarity_threshold
+var runtimeParamRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*(\.[a-z_][a-z0-9_]*)?$`)
 
+func NewPostgresDB(dbConf *cfg.Storage, loger logpkg.Logger) (*gorm.DB, error) {
 	gormConfig := &gorm.Config{
 		NowFunc: pgnow,
 		Logger: gormlogger.New(log.New(os.Stdout, "\r\n", log.LstdFlags), gormlogger.Config{
@@ -54,33 +68,141 @@ func NewPostgresDB(dbConf *cfg.Storage, loger logpkg.Logger) (*gorm.DB, error) {
 		gormConfig.Logger = zapgorm2.New(zapLogger.Unwrap()).LogMode(gormlogger.LogLevel(cfg.GetGORMLogLevel(dbConf.GormV2Conf.LogLevelGormV2)))
 	}
 
+	sqlDB, err := openPostgresPool(primaryPgDsn(dbConf.PgDsn), dbConf)
+	if err != nil {
+		return nil, err
+	}
+	setPoolLimits(sqlDB, dbConf.MaxOpenConn, dbConf.MaxIdleConn, dbConf.MaxConnTtl)
+
 	db, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  connString,
-		PreferSimpleProtocol: dbConf.GormV2Conf.DisableImplicitPreparedStmt,
+		Conn: sqlDB,
 	}), gormConfig)
 	if err != nil {
+		_ = sqlDB.Close()
 		return nil, err
 	}
 
-	sqlDB, err := db.DB()
-	if err != nil {
+	if err = db.Use(errorClassifier{}); err != nil {
+		_ = sqlDB.Close()
 		return nil, err
 	}
-	if dbConf.MaxOpenConn != 0 {
-		sqlDB.SetMaxOpenConns(dbConf.MaxOpenConn)
+
+	if len(dbConf.Replicas) == 0 {
+		return db, nil
 	}
-	if dbConf.MaxIdleConn != 0 {
-		sqlDB.SetMaxIdleConns(dbConf.MaxIdleConn)
+
+	resolver, err := newReplicaResolver(sqlDB, dbConf, loger)
+	if err != nil {
+		_ = sqlDB.Close()
+		return nil, err
 	}
-	if dbConf.MaxConnTtl != 0 {
-		sqlDB.SetConnMaxLifetime(dbConf.MaxConnTtl)
+	if err = db.Use(resolver); err != nil {
+		_ = resolver.Close()
+		_ = sqlDB.Close()
+		return nil, err
 	}
 
 	return db, nil
 }
 
+// openPostgresPool opens a pool of pgx connections to pgDsn having the session params of dbConf
+func openPostgresPool(pgDsn *cfg.PgDsn, dbConf *cfg.Storage) (*sql.DB, error) {
+	connString, err := GetPgDbDsnString(pgDsn)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, err
+	}
+	if dbConf.GormV2Conf.DisableImplicitPreparedStmt {
+		config.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	}
+
+	runtimeParams, err := GetRuntimeParams(dbConf.SessionConf)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range runtimeParams {
+		config.RuntimeParams[k] = v
+	}
+
+	credentialsHook, err := newCredentialsHook(pgDsn)
+	if err != nil {
+		return nil, err
+	}
+	if credentialsHook != nil {
+		return stdlib.OpenDB(*config, stdlib.OptionBeforeConnect(credentialsHook)), nil
+	}
+
+	return stdlib.OpenDB(*config), nil
+}
+
+func setPoolLimits(sqlDB *sql.DB, maxOpenConn, maxIdleConn int, maxConnTtl time.Duration) {
+	if maxOpenConn != 0 {
+		sqlDB.SetMaxOpenConns(maxOpenConn)
+	}
+	if maxIdleConn != 0 {
+		sqlDB.SetMaxIdleConns(maxIdleConn)
+	}
+	if maxConnTtl != 0 {
+		sqlDB.SetConnMaxLifetime(maxConnTtl)
+	}
+}
+
+// GetRuntimeParams validates the session conf and returns the run-time parameters to be set on the connections
+func GetRuntimeParams(conf *cfg.SessionConf) (map[string]string, error) {
+	params := map[string]string{}
+	if conf == nil {
+		return params, nil
+	}
+
+	for k, v := range conf.RuntimeParams {
+		if !runtimeParamRegex.MatchString(k) {
+			return nil, errors.Wrap(errors.ErrInvalidArgument, "invalid runtime param %q", k)
+		}
+		params[k] = v
+	}
+
+	for name, timeout := range map[string]time.Duration{
+		StatementTimeout:                conf.StatementTimeout,
+		LockTimeout:                     conf.LockTimeout,
+		IdleInTransactionSessionTimeout: conf.IdleInTransactionSessionTimeout,
+	} {
+		if timeout == 0 {
+			continue
+		}
+		if _, ok := params[name]; ok {
+			return nil, errors.Wrap(errors.ErrInvalidArgument, "%s is set both as a runtime param and a timeout", name)
+		}
+		// postgres rounds off to milliseconds, sub millisecond timeouts would disable the timeout instead
+		if timeout < time.Millisecond {
+			return nil, errors.Wrap(errors.ErrInvalidArgument, "%s must be at least 1ms, got %s", name, timeout)
+		}
+		params[name] = strconv.FormatInt(timeout.Milliseconds(), 10)
+	}
+
+	if len(conf.SearchPath) != 0 {
+		if _, ok := params[SearchPath]; ok {
+			return nil, errors.Wrap(errors.ErrInvalidArgument, "%s is set both as a runtime param and the search path", SearchPath)
+		}
+
+		schemas := make([]string, 0, len(conf.SearchPath))
+		for _, schema := range conf.SearchPath {
+			if schema == "" {
+				return nil, errors.Wrap(errors.ErrInvalidArgument, "empty schema in search path")
+			}
+			schemas = append(schemas, pgx.Identifier{schema}.Sanitize())
+		}
+		params[SearchPath] = strings.Join(schemas, ", ")
+	}
+
+	return params, nil
+}
+
 func GetPgDbDsnStringFromDBConf(pgdbConf *cfg.Storage) (string, error) {
-	connStr, err := GetPgDbDsnString(pgdbConf.PgDsn)
+	connStr, err := GetPgDbDsnString(primaryPgDsn(pgdbConf.PgDsn))
 	if err != nil {
 		return "", err
 	}
@@ -99,6 +221,7 @@ func GetPgDbDsnString(dsn *cfg.PgDsn) (string, error) {
 	return connStr, nil
 }
 
+// GetPgDsnUrl returns the url of the DSN, the host is overridden by the env var HostEnv if set
 func GetPgDsnUrl(pgDsnConf *cfg.PgDsn) *url.URL {
 	queryVals := url.Values{}
 	queryVals.Set(DBConnSSLMode, pgDsnConf.SSLMode)
@@ -107,15 +230,31 @@ func GetPgDsnUrl(pgDsnConf *cfg.PgDsn) *url.URL {
 		queryVals.Set(DBConnAppName, pgDsnConf.AppName)
 	}
 
-	host := os.Getenv("DB_HOST")
-	if host != "" {
-		pgDsnConf.Host = host
+	for param, file := range map[string]string{
+		DBConnSSLRootCert: pgDsnConf.SSLRootCert,
+		DBConnSSLCert:     pgDsnConf.SSLClientCert,
+		DBConnSSLKey:      pgDsnConf.SSLClientKey,
+	} {
+		if file == "" {
+			continue
+		}
+		if pgDsnConf.SSLCertPath != "" && !filepath.IsAbs(file) {
+			file = filepath.Join(pgDsnConf.SSLCertPath, file)
+		}
+		queryVals.Set(param, file)
+	}
+
+	host := pgDsnConf.Host
+	if pgDsnConf.HostEnv != "" {
+		if envHost := os.Getenv(pgDsnConf.HostEnv); envHost != "" {
+			host = envHost
+		}
 	}
 
 	uri := &url.URL{
 		Scheme:   PostgresSQLSchema,
 		User:     url.UserPassword(pgDsnConf.Username, pgDsnConf.Password),
-		Host:     net.JoinHostPort(pgDsnConf.Host, strconv.Itoa(pgDsnConf.Port)),
+		Host:     net.JoinHostPort(host, strconv.Itoa(pgDsnConf.Port)),
 		Path:     pgDsnConf.Name,
 		RawQuery: queryVals.Encode(),
 	}
@@ -123,6 +262,18 @@ func GetPgDsnUrl(pgDsnConf *cfg.PgDsn) *url.URL {
 	return uri
 }
 
+// primaryPgDsn returns the DSN of the primary whose host is overridden by DBHostEnv if HostEnv is not set.
+// Replicas are overridden only by their own HostEnv.
+func primaryPgDsn(dsn *cfg.PgDsn) *cfg.PgDsn {
+	if dsn.HostEnv != "" {
+		return dsn
+	}
+
+	primary := *dsn
+	primary.HostEnv = DBHostEnv
+	return &primary
+}
+
 func pgnow() time.Time {
 	// postgres supports only microsecond precision.
 	// Hence, we round off now() value from nanoseconds to microseconds precision.
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPgDsnUrl_TLS(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	uri := GetPgDsnUrl(&cfg.PgDsn{
+		Host:          "localhost",
+		Port:          5432,
+		SSLMode:       DBSSLModeVerifyFull,
+		SSLCertPath:   "/etc/certs",
+		SSLRootCert:   "ca.pem",
+		SSLClientCert: "client.pem",
+		SSLClientKey:  "/secrets/client.key",
+	})
+
+	query := uri.Query()
+	a.Equal(DBSSLModeVerifyFull, query.Get(DBConnSSLMode))
+	a.Equal("/etc/certs/ca.pem", query.Get(DBConnSSLRootCert))
+	a.Equal("/etc/certs/client.pem", query.Get(DBConnSSLCert))
+	a.Equal("/secrets/client.key", query.Get(DBConnSSLKey))
+}
+
+// not parallel as it sets the env
+func TestGetPgDsnUrl_HostEnv(t *testing.T) {
+	a := require.New(t)
+	t.Setenv(DBHostEnv, "db.internal")
+	t.Setenv("PRIMARY_HOST", "primary.internal")
+
+	dsn := &cfg.PgDsn{Host: "localhost", Port: 5432}
+	a.Equal("localhost:5432", GetPgDsnUrl(dsn).Host)
+	a.Equal("db.internal:5432", GetPgDsnUrl(primaryPgDsn(dsn)).Host)
+	a.Empty(dsn.HostEnv)
+
+	dsn = &cfg.PgDsn{Host: "localhost", HostEnv: "PRIMARY_HOST", Port: 5432}
+	a.Equal("primary.internal:5432", GetPgDsnUrl(dsn).Host)
+	a.Equal("primary.internal:5432", GetPgDsnUrl(primaryPgDsn(dsn)).Host)
+
+	t.Setenv(DBHostEnv, "")
+	a.Equal("localhost:5432", GetPgDsnUrl(primaryPgDsn(&cfg.PgDsn{Host: "localhost", Port: 5432})).Host)
+}
+
+func TestGetRuntimeParams(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	params, err := GetRuntimeParams(&cfg.SessionConf{
+		StatementTimeout: 30 * time.Second,
+		LockTimeout:      500 * time.Millisecond,
+		SearchPath:       []string{"app", "public"},
+		RuntimeParams:    map[string]string{"timezone": "UTC"},
+	})
+	a.NoError(err)
+	a.Equal(map[string]string{
+		StatementTimeout: "30000",
+		LockTimeout:      "500",
+		SearchPath:       `"app", "public"`,
+		"timezone":       "UTC",
+	}, params)
+
+	for _, conf := range []*cfg.SessionConf{
+		{StatementTimeout: time.Microsecond},
+		{LockTimeout: time.Second, RuntimeParams: map[string]string{LockTimeout: "1s"}},
+		{SearchPath: []string{""}},
+		{RuntimeParams: map[string]string{"timezone; drop": "UTC"}},
+	} {
+		_, err = GetRuntimeParams(conf)
+		a.ErrorIs(err, errors.ErrInvalidArgument)
+	}
+}
@@ -0,0 +1,136 @@
+// Package storagetest provides a fake database/sql connector to test the storage code without postgres
+package storagetest
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+)
+
+// Connector is a database/sql connector whose connections call its hooks, e.g. to record the statements
+// or to emulate the server state. The hooks are called concurrently by the connections of the pool,
+// a nil hook succeeds without doing anything.
+//
+//	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(connector)}), &gorm.Config{DisableAutomaticPing: true})
+type Connector struct {
+	OnConnect  func(ctx context.Context) error
+	OnBegin    func(conn *Conn) error
+	OnCommit   func(conn *Conn) error
+	OnRollback func(conn *Conn) error
+	OnExec     func(ctx context.Context, conn *Conn, query string, args []driver.NamedValue) (driver.Result, error)
+	// OnQuery answers no rows if nil
+	OnQuery func(ctx context.Context, conn *Conn, query string, args []driver.NamedValue) (driver.Rows, error)
+	OnPing  func(conn *Conn) error
+	// OnClose is called once the connection is discarded by the pool, which ends its session
+	OnClose func(conn *Conn)
+}
+
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	if c.OnConnect != nil {
+		if err := c.OnConnect(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return &Conn{connector: c}, nil
+}
+
+func (c *Connector) Driver() driver.Driver {
+	return nil
+}
+
+// Conn is a connection of Connector, its pointer identifies the session in the hooks
+type Conn struct {
+	connector *Connector
+}
+
+func (c *Conn) Prepare(string) (driver.Stmt, error) {
+	return nil, driver.ErrSkip
+}
+
+func (c *Conn) Close() error {
+	if c.connector.OnClose != nil {
+		c.connector.OnClose(c)
+	}
+	return nil
+}
+
+func (c *Conn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *Conn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	if c.connector.OnBegin != nil {
+		if err := c.connector.OnBegin(c); err != nil {
+			return nil, err
+		}
+	}
+	return &tx{conn: c}, nil
+}
+
+func (c *Conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if c.connector.OnExec != nil {
+		return c.connector.OnExec(ctx, c, query, args)
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *Conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if c.connector.OnQuery != nil {
+		return c.connector.OnQuery(ctx, c, query, args)
+	}
+	return NewRows(nil), nil
+}
+
+func (c *Conn) Ping(context.Context) error {
+	if c.connector.OnPing != nil {
+		return c.connector.OnPing(c)
+	}
+	return nil
+}
+
+type tx struct {
+	conn *Conn
+}
+
+func (t *tx) Commit() error {
+	if t.conn.connector.OnCommit != nil {
+		return t.conn.connector.OnCommit(t.conn)
+	}
+	return nil
+}
+
+func (t *tx) Rollback() error {
+	if t.conn.connector.OnRollback != nil {
+		return t.conn.connector.OnRollback(t.conn)
+	}
+	return nil
+}
+
+// NewRows answers the values as the rows of the columns
+//
+//	storagetest.NewRows([]string{"version", "dirty"}, []driver.Value{int64(1), false})
+func NewRows(columns []string, values ...[]driver.Value) driver.Rows {
+	return &rows{columns: columns, values: values}
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Close() error {
+	return nil
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
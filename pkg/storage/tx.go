@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// TxOption configures the transaction started by TxManager.RunInTx
+type TxOption func(opts *sql.TxOptions)
+
+// WithIsolationLevel sets the isolation level of the transaction, default is the one of the database
+func WithIsolationLevel(level sql.IsolationLevel) TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.Isolation = level
+	}
+}
+
+// WithReadOnly starts a read only transaction, which is begun on a replica if cfg.Storage has any
+func WithReadOnly() TxOption {
+	return func(opts *sql.TxOptions) {
+		opts.ReadOnly = true
+	}
+}
+
+type TxManager interface {
+	// RunInTx runs fn in a transaction which is committed if fn returns nil and rolled back otherwise.
+	// The transaction is propagated through the ctx passed to fn, repositories must get it using DB.
+	// Nested calls run in a savepoint of the outer transaction and ignore the options.
+	// Serialization failures and deadlocks of the outermost call are retried as per the cfg.RetryParams of the manager,
+	// hence fn must not have side effects outside the transaction.
+	RunInTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error
+	// DB returns the transaction of ctx if one is active, the pool otherwise
+	DB(ctx context.Context) *gorm.DB
+}
+
+type txCtxKey struct {
+	manager *txManager
+}
+
+type txManager struct {
+	db          *gorm.DB
+	retryParams *cfg.RetryParams
+}
+
+// NewTxManager creates a TxManager over db, retryParams is optional
+func NewTxManager(db *gorm.DB, retryParams *cfg.RetryParams) TxManager {
+	return &txManager{
+		db:          db,
+		retryParams: retryParams,
+	}
+}
+
+func (m *txManager) DB(ctx context.Context) *gorm.DB {
+	if tx, ok := ctx.Value(txCtxKey{manager: m}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+
+	return m.db.WithContext(ctx)
+}
+
+func (m *txManager) RunInTx(ctx context.Context, fn func(ctx context.Context) error, opts ...TxOption) error {
+	if tx, ok := ctx.Value(txCtxKey{manager: m}).(*gorm.DB); ok {
+		// gorm runs the nested transactions in a savepoint
+		return tx.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txCtxKey{manager: m}, tx))
+		})
+	}
+
+	txOpts := &sql.TxOptions{}
+	for _, opt := range opts {
+		opt(txOpts)
+	}
+
+	maxAttempts := m.retryParams.GetMaxAttempts()
+	for attempt := uint(0); ; attempt++ {
+		err := m.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			return fn(context.WithValue(ctx, txCtxKey{manager: m}, tx))
+		}, txOpts)
+		if err == nil || !isSerializationFailure(err) || attempt >= maxAttempts {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Wrap(err, "transaction retry aborted: %s", ctx.Err())
+		case <-time.After(m.retryParams.GetBackoff(attempt + 1)):
+		}
+	}
+}
+
+// isSerializationFailure checks whether the transaction failed due to a concurrent transaction,
+// in which case it can be retried
+func isSerializationFailure(err error) bool {
+	pgErr := &pgconn.PgError{}
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+
+	return pgErr.Code == PgSerializationFailure || pgErr.Code == PgDeadlockDetected
+}
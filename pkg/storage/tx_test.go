@@ -0,0 +1,154 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/nitesh237/go-server-template/pkg/cfg"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+	"github.com/nitesh237/go-server-template/pkg/storage/storagetest"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newTestTxManager(a *require.Assertions, retryParams *cfg.RetryParams) (TxManager, *fakeTxServer) {
+	server := &fakeTxServer{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: sql.OpenDB(server.connector())}), &gorm.Config{DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	a.NoError(err)
+
+	return NewTxManager(db, retryParams), server
+}
+
+func TestTxManager_RetrySerializationFailure(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	manager, server := newTestTxManager(a, &cfg.RetryParams{RegularInterval: &cfg.RegularInterval{Interval: time.Millisecond, MaxAttempts: 2}})
+	ctx := context.Background()
+
+	// retried until it succeeds
+	calls := 0
+	a.NoError(manager.RunInTx(ctx, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return &pgconn.PgError{Code: PgSerializationFailure}
+		}
+		return nil
+	}))
+	a.Equal(3, calls)
+	a.Equal([]string{"BEGIN", "ROLLBACK", "BEGIN", "ROLLBACK", "BEGIN", "COMMIT"}, server.flush())
+
+	// retries exhausted
+	calls = 0
+	err := manager.RunInTx(ctx, func(context.Context) error {
+		calls++
+		return &pgconn.PgError{Code: PgDeadlockDetected}
+	})
+	a.True(isSerializationFailure(err))
+	a.Equal(3, calls)
+	server.flush()
+
+	// other errors are not retried
+	calls = 0
+	a.ErrorIs(manager.RunInTx(ctx, func(context.Context) error {
+		calls++
+		return errors.ErrInvalidArgument
+	}), errors.ErrInvalidArgument)
+	a.Equal(1, calls)
+	a.Equal([]string{"BEGIN", "ROLLBACK"}, server.flush())
+
+	// retry is aborted once ctx is done
+	ctx, cancel := context.WithCancel(ctx)
+	calls = 0
+	err = manager.RunInTx(ctx, func(context.Context) error {
+		calls++
+		cancel()
+		return &pgconn.PgError{Code: PgSerializationFailure}
+	})
+	a.ErrorContains(err, "transaction retry aborted")
+	a.Equal(1, calls)
+}
+
+func TestTxManager_Nested(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	manager, server := newTestTxManager(a, nil)
+	ctx := context.Background()
+
+	a.NoError(manager.RunInTx(ctx, func(ctx context.Context) error {
+		outer := manager.DB(ctx)
+		_, ok := outer.Statement.ConnPool.(gorm.TxCommitter)
+		a.True(ok, "DB does not return the transaction")
+
+		// the failure of the nested call rolls back its savepoint only
+		a.ErrorIs(manager.RunInTx(ctx, func(ctx context.Context) error {
+			return errors.ErrInvalidArgument
+		}), errors.ErrInvalidArgument)
+
+		return manager.RunInTx(ctx, func(ctx context.Context) error {
+			return nil
+		})
+	}))
+
+	statements := server.flush()
+	a.Len(statements, 5)
+	a.Equal("BEGIN", statements[0])
+	a.True(strings.HasPrefix(statements[1], "SAVEPOINT "))
+	a.Equal("ROLLBACK TO "+statements[1], statements[2])
+	a.True(strings.HasPrefix(statements[3], "SAVEPOINT "))
+	a.Equal("COMMIT", statements[4])
+
+	// DB outside of a transaction is the pool
+	_, ok := manager.DB(ctx).Statement.ConnPool.(gorm.TxCommitter)
+	a.False(ok)
+}
+
+// fakeTxServer is a storagetest.Connector recording the transaction statements
+type fakeTxServer struct {
+	mu         sync.Mutex
+	statements []string
+}
+
+func (s *fakeTxServer) connector() *storagetest.Connector {
+	return &storagetest.Connector{
+		OnBegin: func(*storagetest.Conn) error {
+			s.record("BEGIN")
+			return nil
+		},
+		OnCommit: func(*storagetest.Conn) error {
+			s.record("COMMIT")
+			return nil
+		},
+		OnRollback: func(*storagetest.Conn) error {
+			s.record("ROLLBACK")
+			return nil
+		},
+		OnExec: func(_ context.Context, _ *storagetest.Conn, query string, _ []driver.NamedValue) (driver.Result, error) {
+			s.record(query)
+			return driver.RowsAffected(0), nil
+		},
+	}
+}
+
+func (s *fakeTxServer) record(statement string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statements = append(s.statements, statement)
+}
+
+// flush returns the recorded statements and clears them
+func (s *fakeTxServer) flush() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	statements := s.statements
+	s.statements = nil
+	return statements
+}
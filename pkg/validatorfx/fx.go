@@ -1,10 +1,45 @@
 package validatorfx
 
 import (
+	ut "github.com/go-playground/universal-translator"
 	"github.com/go-playground/validator/v10"
 	"go.uber.org/fx"
 )
 
 var (
-	FxModule = fx.Module("validator", fx.Provide(validator.New))
+	// FxModule provides the shared validator created by NewValidator and its translator.
+	// The validator differs from validator.New in the required tag of the struct fields and the json field names,
+	// refer NewValidator.
+	FxModule = fx.Module("validator", fx.Provide(NewValidatorProvider))
 )
+
+type ValidatorProviderParams struct {
+	fx.In
+
+	CustomValidations []*CustomValidation `group:"CustomValidations"`
+}
+
+type ValidatorProviderResult struct {
+	fx.Out
+
+	Validate   *validator.Validate
+	Translator ut.Translator
+}
+
+func NewValidatorProvider(p ValidatorProviderParams) (ValidatorProviderResult, error) {
+	v, trans, err := NewValidator(p.CustomValidations...)
+	if err != nil {
+		return ValidatorProviderResult{}, err
+	}
+
+	return ValidatorProviderResult{
+		Validate:   v,
+		Translator: trans,
+	}, nil
+}
+
+// AsCustomValidation annotates the constructor of *CustomValidation to be registered with the shared validator
+// e.g. fx.Provide(validatorfx.AsCustomValidation(NewSkuValidation))
+func AsCustomValidation(f any) any {
+	return fx.Annotate(f, fx.ResultTags(`group:"CustomValidations"`))
+}
@@ -0,0 +1,161 @@
+package validatorfx
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/locales"
+	"github.com/go-playground/locales/en"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	entranslations "github.com/go-playground/validator/v10/translations/en"
+	"github.com/nitesh237/go-server-template/pkg/errors"
+)
+
+// CustomValidation is a validation tag registered with the shared validator
+type CustomValidation struct {
+	// Tag used in the `validate` struct tag e.g. sku
+	Tag  string
+	Func validator.FuncCtx
+	// Message is the translated reason of the failure, {0} is replaced by the field name
+	// and {1} by the param of the tag e.g. "{0} must be a valid sku"
+	Message string
+	// CallValidationEvenIfNull calls Func even if the field is nil
+	CallValidationEvenIfNull bool
+}
+
+// NewValidator creates a validator which names the fields by their json tags, registers the custom validations
+// and the english translations for all the tags.
+// Unlike validator.New, the required tag on a struct field fails for its zero value (validator.WithRequiredStructEnabled)
+// and the FieldError.Field and Namespace use the json names e.g. address.city instead of Address.City.
+func NewValidator(customValidations ...*CustomValidation) (*validator.Validate, ut.Translator, error) {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(jsonTagName)
+
+	enLocale := en.New()
+	trans, _ := ut.New(enLocale, enLocale).GetTranslator(enLocale.Locale())
+	if err := entranslations.RegisterDefaultTranslations(v, trans); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to register default translations")
+	}
+
+	for _, cv := range customValidations {
+		if err := v.RegisterValidationCtx(cv.Tag, cv.Func, cv.CallValidationEvenIfNull); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to register validation %s", cv.Tag)
+		}
+
+		if cv.Message == "" {
+			continue
+		}
+
+		err := v.RegisterTranslation(cv.Tag, trans, registerTranslationFn(cv.Tag, cv.Message), translateFieldError)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to register translation for %s", cv.Tag)
+		}
+	}
+
+	return v, trans, nil
+}
+
+// ConfigureValidator makes v, created elsewhere e.g. the validator of gin binding, name the fields by their json tags
+// and translate the default tags like NewValidator using trans, the translator returned by NewValidator.
+// Returns the translator to be passed to GetFieldErrors for the errors of v.
+// It must be called before v validates anything as v is not safe to configure concurrently.
+func ConfigureValidator(v *validator.Validate, trans ut.Translator) (ut.Translator, error) {
+	v.RegisterTagNameFunc(jsonTagName)
+
+	shared := sharedTranslator{Translator: trans}
+	if err := entranslations.RegisterDefaultTranslations(v, shared); err != nil {
+		return nil, errors.Wrap(err, "failed to register default translations")
+	}
+
+	return shared, nil
+}
+
+// sharedTranslator reuses the texts already added to the Translator by NewValidator, so that the translations
+// can be registered with another validator without conflicts
+type sharedTranslator struct {
+	ut.Translator
+}
+
+func (t sharedTranslator) Add(key any, text string, override bool) error {
+	return ignoreConflict(t.Translator.Add(key, text, override))
+}
+
+func (t sharedTranslator) AddCardinal(key any, text string, rule locales.PluralRule, override bool) error {
+	return ignoreConflict(t.Translator.AddCardinal(key, text, rule, override))
+}
+
+func (t sharedTranslator) AddOrdinal(key any, text string, rule locales.PluralRule, override bool) error {
+	return ignoreConflict(t.Translator.AddOrdinal(key, text, rule, override))
+}
+
+func (t sharedTranslator) AddRange(key any, text string, rule locales.PluralRule, override bool) error {
+	return ignoreConflict(t.Translator.AddRange(key, text, rule, override))
+}
+
+func ignoreConflict(err error) error {
+	conflictErr := &ut.ErrConflictingTranslation{}
+	if errors.As(err, &conflictErr) {
+		return nil
+	}
+
+	return err
+}
+
+// GetFieldErrors maps the validation errors into field→reason pairs, fields are named by their path
+// in the struct e.g. address.city. Reasons are translated if trans is not nil.
+// Returns false if err is not a validation error.
+func GetFieldErrors(err error, trans ut.Translator) (map[string]string, bool) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	details := make(map[string]string, len(validationErrs))
+	for _, fe := range validationErrs {
+		reason := fe.Error()
+		if trans != nil {
+			reason = fe.Translate(trans)
+		}
+		details[getFieldPath(fe)] = reason
+	}
+
+	return details, true
+}
+
+// getFieldPath strips the name of the top level struct from the namespace of the field
+func getFieldPath(fe validator.FieldError) string {
+	ns := fe.Namespace()
+	if idx := strings.Index(ns, "."); idx >= 0 {
+		return ns[idx+1:]
+	}
+
+	return fe.Field()
+}
+
+func jsonTagName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return field.Name
+	default:
+		return name
+	}
+}
+
+func registerTranslationFn(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translateFieldError(trans ut.Translator, fe validator.FieldError) string {
+	msg, err := trans.T(fe.Tag(), fe.Field(), fe.Param())
+	if err != nil {
+		return fe.Error()
+	}
+
+	return msg
+}
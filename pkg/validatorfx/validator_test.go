@@ -0,0 +1,50 @@
+package validatorfx
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/require"
+)
+
+type address struct {
+	City string `json:"city" validate:"required"`
+}
+
+type order struct {
+	Sku     string  `json:"sku" validate:"sku"`
+	Qty     int     `json:"qty" validate:"gte=1"`
+	Address address `json:"address"`
+}
+
+func TestNewValidator_FieldErrors(t *testing.T) {
+	t.Parallel()
+	a := require.New(t)
+
+	v, trans, err := NewValidator(&CustomValidation{
+		Tag: "sku",
+		Func: func(_ context.Context, fl validator.FieldLevel) bool {
+			return strings.HasPrefix(fl.Field().String(), "SKU-")
+		},
+		Message: "{0} must be a valid sku",
+	})
+	a.NoError(err)
+
+	a.NoError(v.Struct(&order{Sku: "SKU-1", Qty: 1, Address: address{City: "Pune"}}))
+
+	err = v.Struct(&order{Sku: "1"})
+	a.Error(err)
+
+	details, ok := GetFieldErrors(err, trans)
+	a.True(ok)
+	a.Equal(map[string]string{
+		"sku":          "sku must be a valid sku",
+		"qty":          "qty must be 1 or greater",
+		"address.city": "city is a required field",
+	}, details)
+
+	_, ok = GetFieldErrors(context.Canceled, trans)
+	a.False(ok)
+}